@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+	"github.com/yeslayla/godot-build-tools/utils"
+)
+
+// Cache manages the download cache with the `list` and `prune` subcommands.
+func Cache(logger logging.Logger, args []string) bool {
+	if len(args) == 0 {
+		logger.Errorf("Usage: gbt cache <list|prune> [options]")
+		return false
+	}
+
+	switch args[0] {
+	case "list":
+		return cacheList(logger, args[1:])
+	case "prune":
+		return cachePrune(logger, args[1:])
+	}
+
+	logger.Errorf("Unknown cache command %q, expected list or prune", args[0])
+	return false
+}
+
+// cacheList prints every file in the download cache.
+func cacheList(logger logging.Logger, args []string) bool {
+	flags := flag.NewFlagSet("cache list", flag.ContinueOnError)
+	dir := flags.String("dir", "", "Cache directory to use")
+	if err := flags.Parse(args); err != nil {
+		return false
+	}
+
+	cache := internal.NewCache(cacheDir(logger, *dir), logger)
+	entries, err := cache.List()
+	if err != nil {
+		logger.Errorf("Failed to list cache: %s", err)
+		return false
+	}
+
+	logger.Infof("Cache directory: %s", cache.Dir())
+	var total int64
+	for _, entry := range entries {
+		relPath, _ := filepath.Rel(cache.Dir(), entry.Path)
+		logger.Infof("%s (%s, last used %s)", relPath, utils.FormatBytes(entry.Size), entry.ModTime.Format(time.RFC3339))
+		total += entry.Size
+	}
+	logger.Infof("%d file(s), %s", len(entries), utils.FormatBytes(total))
+
+	return true
+}
+
+// cachePrune removes files from the download cache.
+func cachePrune(logger logging.Logger, args []string) bool {
+	flags := flag.NewFlagSet("cache prune", flag.ContinueOnError)
+	dir := flags.String("dir", "", "Cache directory to use")
+	olderThan := flags.Duration("older-than", 0, "Only remove files unused for longer than this, such as 720h")
+	if err := flags.Parse(args); err != nil {
+		return false
+	}
+
+	cache := internal.NewCache(cacheDir(logger, *dir), logger)
+	removed, err := cache.Prune(*olderThan)
+	for _, entry := range removed {
+		logger.Infof("Removed %s", entry.Path)
+	}
+	if err != nil {
+		logger.Errorf("Failed to prune cache: %s", err)
+		return false
+	}
+
+	var total int64
+	for _, entry := range removed {
+		total += entry.Size
+	}
+	logger.Infof("Removed %d file(s), freed %s", len(removed), utils.FormatBytes(total))
+
+	return true
+}
+
+// cacheDir returns the cache directory given on the command line, falling back to the
+// directory in the build config when one is present.
+func cacheDir(logger logging.Logger, flagDir string) string {
+	if flagDir != "" {
+		return flagDir
+	}
+	if _, err := os.Stat(internal.BuildConfigFile); err != nil {
+		return ""
+	}
+	return internal.LoadBuildConfig(logger).Cache.Dir
+}
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"flag"
+	"os"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+// Engines manages installed Godot engines with the `list`, `install`, `remove` and `use` subcommands.
+func Engines(logger logging.Logger, args []string) bool {
+	if len(args) == 0 {
+		logger.Errorf("Usage: gbt engines <list|install|remove|use> [options]")
+		return false
+	}
+
+	switch args[0] {
+	case "list":
+		return enginesList(logger, args[1:])
+	case "install":
+		return enginesInstall(logger, args[1:])
+	case "remove":
+		return enginesRemove(logger, args[1:])
+	case "use":
+		return Use(logger, args[1:])
+	}
+
+	logger.Errorf("Unknown engines command %q, expected list, install, remove or use", args[0])
+	return false
+}
+
+// Use points the `godot` shim at an installed engine.
+func Use(logger logging.Logger, args []string) bool {
+	engine, ok := parseEngineArgs(logger, "use", args)
+	if !ok {
+		return false
+	}
+
+	engines := internal.NewEngineManager(internal.CurrentTargetOS(), logger, &internal.EngineManagerOptions{})
+	shimPath, err := engines.Use(engine)
+	if err != nil {
+		logger.Errorf("Failed to switch engine: %s", err)
+		return false
+	}
+
+	logger.Infof("Now using Godot %s, available as %s", engine, shimPath)
+	return true
+}
+
+// enginesList prints every installed engine, marking the one the shim points at.
+func enginesList(logger logging.Logger, args []string) bool {
+	engines := internal.NewEngineManager(internal.CurrentTargetOS(), logger, &internal.EngineManagerOptions{})
+	installed, err := engines.List()
+	if err != nil {
+		logger.Errorf("Failed to list engines: %s", err)
+		return false
+	}
+
+	if len(installed) == 0 {
+		logger.Infof("No engines installed in %s", engines.Dir())
+		return true
+	}
+
+	for _, engine := range installed {
+		marker := " "
+		if engine.Active {
+			marker = "*"
+		}
+		logger.Infof("%s %s (%s)", marker, engine.Engine, engine.Binary)
+	}
+	return true
+}
+
+// enginesInstall downloads and installs an engine, resolving version constraints such as "4.2".
+func enginesInstall(logger logging.Logger, args []string) bool {
+	flags := flag.NewFlagSet("engines install", flag.ContinueOnError)
+	release := flags.String("release", "stable", "Release to install, such as stable, rc1 or latest-rc")
+	mono := flags.Bool("mono", false, "Install the .NET build")
+	arch := flags.String("arch", "", "Architecture to install, defaults to the current architecture")
+	use := flags.Bool("use", false, "Point the godot shim at the engine once installed")
+	if err := flags.Parse(args); err != nil {
+		return false
+	}
+	if flags.NArg() != 1 {
+		logger.Errorf("Usage: gbt engines install [options] <version>")
+		return false
+	}
+
+	targetOS := internal.CurrentTargetOS()
+	targetArch := internal.CurrentTargetArch()
+	if *arch != "" {
+		var err error
+		if targetArch, err = internal.ParseTargetArch(*arch); err != nil {
+			logger.Errorf("%s", err)
+			return false
+		}
+	}
+
+	downloader := internal.NewDownloader(targetOS, logger, downloaderOptions(logger))
+	engine := internal.GodotEngine{
+		Version: flags.Arg(0),
+		Release: *release,
+		Mono:    *mono,
+	}
+	if exact, ok := internal.ExactVersion(engine.Version); ok {
+		engine.Version = exact
+	}
+
+	if internal.NeedsVersionResolution(engine) {
+		resolved, err := internal.ResolveEngine(downloader.VersionIndex(), engine)
+		if err != nil {
+			logger.Errorf("Failed to resolve Godot version: %s", err)
+			return false
+		}
+		logger.Infof("Resolved Godot %s (%s) to %s", engine.Version, engine.Release, resolved)
+		engine = resolved
+	}
+
+	logger.Infof("Downloading Godot %s", engine)
+	godotPackage, err := downloader.DownloadGodot(targetOS, targetArch, engine, "")
+	if err != nil {
+		logger.Errorf("Failed to download Godot: %s", err)
+		return false
+	}
+
+	logger.Infof("Installing Godot %s", engine)
+	godotBin, err := downloader.InstallGodot(godotPackage, targetOS, targetArch, engine, "")
+	if err != nil {
+		logger.Errorf("Failed to install Godot: %s", err)
+		return false
+	}
+	logger.Infof("Godot binary: %s", godotBin)
+
+	if *use {
+		return Use(logger, []string{engine.String()})
+	}
+	return true
+}
+
+// downloaderOptions returns the download settings of the build config when there is one, so
+// engines are installed from the same sources, through the same proxy and cache as builds.
+func downloaderOptions(logger logging.Logger) *internal.DownloaderOptions {
+	if _, err := os.Stat(internal.BuildConfigFile); err != nil {
+		return &internal.DownloaderOptions{}
+	}
+	return internal.LoadBuildConfig(logger).DownloaderOptions()
+}
+
+// enginesRemove uninstalls an engine.
+func enginesRemove(logger logging.Logger, args []string) bool {
+	engine, ok := parseEngineArgs(logger, "remove", args)
+	if !ok {
+		return false
+	}
+
+	engines := internal.NewEngineManager(internal.CurrentTargetOS(), logger, &internal.EngineManagerOptions{})
+	if err := engines.Remove(engine); err != nil {
+		logger.Errorf("Failed to remove engine: %s", err)
+		return false
+	}
+
+	logger.Infof("Removed Godot %s", engine)
+	return true
+}
+
+// parseEngineArgs parses the engine name argument of a subcommand, such as "4.2.1-stable-mono".
+func parseEngineArgs(logger logging.Logger, command string, args []string) (internal.GodotEngine, bool) {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return internal.GodotEngine{}, false
+	}
+	if flags.NArg() != 1 {
+		logger.Errorf("Usage: gbt %s <version>[-release][-mono]", command)
+		return internal.GodotEngine{}, false
+	}
+
+	engine, err := internal.ParseEngineName(flags.Arg(0))
+	if err != nil {
+		logger.Errorf("%s", err)
+		return engine, false
+	}
+	return engine, true
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"flag"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+	"github.com/yeslayla/godot-build-tools/steps"
+)
+
+// Fetch downloads the packages the build config needs into the vendor directory, so builds
+// can later run with --offline.
+func Fetch(logger logging.Logger, args []string) bool {
+	flags := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	targetOSName := flags.String("os", "", "Target OS to fetch for (linux, windows, macos), defaults to the current OS")
+	arch := flags.String("arch", "", "Architecture to fetch for, defaults to the configured or current architecture")
+	templates := flags.Bool("templates", true, "Fetch the export templates as well as Godot")
+	if err := flags.Parse(args); err != nil {
+		return false
+	}
+
+	targetOS := internal.CurrentTargetOS()
+	if *targetOSName != "" {
+		var err error
+		if targetOS, err = internal.ParseTargetOS(*targetOSName); err != nil {
+			logger.Errorf("%s", err)
+			return false
+		}
+	}
+
+	config := internal.LoadBuildConfig(logger)
+	if config.Download.Offline {
+		logger.Errorf("Can't fetch packages in offline mode, remove `offline` from [download]")
+		return false
+	}
+	if *arch != "" {
+		if _, err := internal.ParseTargetArch(*arch); err != nil {
+			logger.Errorf("%s", err)
+			return false
+		}
+		config.Godot.Arch = *arch
+	}
+
+	// Resolve the version the build would use, pinning the checksums from the lockfile
+	if err := steps.GodotResolve(logger, targetOS, &config, false); err != nil {
+		logger.Errorf("%s", err)
+		return false
+	}
+
+	godot := config.Godot
+	artifacts, err := internal.BuildArtifacts(godot, targetOS, godot.TargetArch(), true, *templates)
+	if err != nil {
+		logger.Errorf("Failed to list required packages: %s", err)
+		return false
+	}
+
+	downloader := internal.NewDownloader(targetOS, logger, config.DownloaderOptions())
+	for _, artifact := range artifacts {
+		vendorPath, err := downloader.VendorArtifact(targetOS, artifact)
+		if err != nil {
+			logger.Errorf("Failed to fetch %s: %s", artifact.FileName, err)
+			return false
+		}
+		logger.Infof("Vendored %s", vendorPath)
+	}
+
+	return true
+}
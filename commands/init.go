@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+var nonAlphanumericPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Init generates a .godot-build.toml for the Godot project in the working directory.
+func Init(logger logging.Logger, args []string) bool {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	force := flags.Bool("force", false, "Overwrite an existing build config")
+	if err := flags.Parse(args); err != nil {
+		return false
+	}
+
+	if _, err := os.Stat(internal.BuildConfigFile); err == nil && !*force {
+		logger.Errorf("%s already exists, use --force to overwrite it", internal.BuildConfigFile)
+		return false
+	}
+
+	project, err := internal.LoadGodotProject(".")
+	if err != nil {
+		if os.IsNotExist(err) {
+			logger.Errorf("No project.godot found, please run `gbt init` from the root of a Godot project")
+		} else {
+			logger.Errorf("Failed to load Godot project: %s", err)
+		}
+		return false
+	}
+
+	version, guessed, err := project.EngineVersion()
+	if err != nil {
+		logger.Errorf("%s", err)
+		return false
+	}
+	if guessed {
+		logger.Warnf("project.godot doesn't record the engine version, set `version` under [godot] in %s, such as %q", internal.BuildConfigFile, version)
+	} else {
+		logger.Infof("Detected Godot %s", version)
+	}
+
+	presets, err := internal.LoadExportPresets(".")
+	if err != nil {
+		logger.Errorf("Failed to load export presets: %s", err)
+		return false
+	}
+	logger.Infof("Found %d export preset(s)", len(presets))
+
+	content := generateBuildConfig(project, version, guessed, presets)
+	if err := os.WriteFile(internal.BuildConfigFile, []byte(content), 0644); err != nil {
+		logger.Errorf("Failed to write %s: %s", internal.BuildConfigFile, err)
+		return false
+	}
+
+	logger.Infof("Wrote %s", internal.BuildConfigFile)
+	return true
+}
+
+// generateBuildConfig returns the contents of a commented build config for the given project.
+// A guessed version is only suggested in a comment, so it isn't pinned without being checked.
+func generateBuildConfig(project *internal.GodotProject, version string, guessed bool, presets []internal.ExportPreset) string {
+	var b strings.Builder
+
+	b.WriteString("# Godot Build Tools configuration\n")
+	if project.Name != "" {
+		fmt.Fprintf(&b, "# Generated by `gbt init` for %s\n", project.Name)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("[godot]\n")
+	if guessed {
+		b.WriteString("# project.godot doesn't record the engine version, set the version the\n")
+		b.WriteString("# project is made with, such as:\n")
+		fmt.Fprintf(&b, "# version = %s\n", strconv.Quote(version))
+	} else {
+		b.WriteString("# Version of the Godot engine used to build the project\n")
+		fmt.Fprintf(&b, "version = %s\n", strconv.Quote(version))
+	}
+	b.WriteString("# Release channel of the engine, such as \"stable\", \"rc1\" or \"beta2\"\n")
+	b.WriteString("release = \"stable\"\n")
+	if project.UsesCSharp() {
+		b.WriteString("# Use the .NET build of Godot for C# support\n")
+		b.WriteString("mono = true\n")
+	}
+
+	if len(presets) == 0 {
+		b.WriteString("\n")
+		b.WriteString("# No export presets were found in export_presets.cfg.\n")
+		b.WriteString("# Add presets in the Godot editor, then list them here:\n")
+		b.WriteString("#\n")
+		b.WriteString("# [[export]]\n")
+		b.WriteString("# preset = \"Linux/X11\"\n")
+		b.WriteString("# path = \"build/linux/game.x86_64\"\n")
+		return b.String()
+	}
+
+	for _, preset := range presets {
+		exportPath := preset.ExportPath
+		if exportPath == "" {
+			exportPath = defaultExportPath(project, preset)
+		}
+
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "# Export preset for %s\n", preset.Platform)
+		b.WriteString("[[export]]\n")
+		fmt.Fprintf(&b, "preset = %s\n", strconv.Quote(preset.Name))
+		fmt.Fprintf(&b, "path = %s\n", strconv.Quote(exportPath))
+	}
+
+	return b.String()
+}
+
+// defaultExportPath returns an export path for a preset that doesn't specify one.
+func defaultExportPath(project *internal.GodotProject, preset internal.ExportPreset) string {
+	name := slugify(project.Name)
+	if name == "" {
+		name = "game"
+	}
+
+	var fileName string
+	switch preset.Platform {
+	case "Linux/X11", "Linux":
+		fileName = name + ".x86_64"
+	case "Windows Desktop":
+		fileName = name + ".exe"
+	case "macOS", "Mac OSX":
+		fileName = name + ".zip"
+	case "Web", "HTML5":
+		fileName = "index.html"
+	case "Android":
+		fileName = name + ".apk"
+	case "iOS":
+		fileName = name + ".ipa"
+	default:
+		fileName = name
+	}
+
+	return path.Join("build", slugify(preset.Name), fileName)
+}
+
+// slugify returns a lowercase, filesystem-friendly version of a name.
+func slugify(name string) string {
+	return strings.Trim(nonAlphanumericPattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
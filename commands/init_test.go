@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+// decodeBuildConfig decodes a generated build config, failing the test if it isn't valid TOML.
+func decodeBuildConfig(t *testing.T, content string) internal.BuildConfig {
+	t.Helper()
+
+	var config internal.BuildConfig
+	if _, err := toml.Decode(content, &config); err != nil {
+		t.Fatalf("failed to decode generated config: %s\n%s", err, content)
+	}
+	return config
+}
+
+func TestGenerateBuildConfig(t *testing.T) {
+	project := &internal.GodotProject{Name: "Space Game", ConfigVersion: 5, Features: []string{"4.2", "C#", "Forward Plus"}}
+	presets := []internal.ExportPreset{
+		{Name: "Linux/X11", Platform: "Linux/X11"},
+		{Name: "Windows Desktop", Platform: "Windows Desktop", ExportPath: "build/windows/Space Game.exe"},
+		{Name: "Web", Platform: "Web"},
+	}
+
+	content := generateBuildConfig(project, "4.2", false, presets)
+	config := decodeBuildConfig(t, content)
+
+	if config.Godot.Version != "4.2" || config.Godot.Release != "stable" || !config.Godot.Mono {
+		t.Errorf("unexpected [godot] section: %+v", config.Godot)
+	}
+	expected := []internal.BuildConfigExport{
+		{Preset: "Linux/X11", Path: "build/linux-x11/space-game.x86_64"},
+		{Preset: "Windows Desktop", Path: "build/windows/Space Game.exe"},
+		{Preset: "Web", Path: "build/web/index.html"},
+	}
+	if !reflect.DeepEqual(config.Export, expected) {
+		t.Errorf("expected exports %+v, got %+v", expected, config.Export)
+	}
+	if !strings.Contains(content, "# Generated by `gbt init` for Space Game\n") {
+		t.Errorf("expected the project name in the header, got:\n%s", content)
+	}
+}
+
+func TestGenerateBuildConfigGuessedVersion(t *testing.T) {
+	project := &internal.GodotProject{Name: "Platformer", ConfigVersion: 4}
+
+	content := generateBuildConfig(project, "3.5.3", true, nil)
+	config := decodeBuildConfig(t, content)
+
+	if config.Godot.Version != "" {
+		t.Errorf("expected a guessed version not to be pinned, got %q", config.Godot.Version)
+	}
+	if !strings.Contains(content, "\n# version = \"3.5.3\"\n") {
+		t.Errorf("expected the guessed version as a commented placeholder, got:\n%s", content)
+	}
+	if len(config.Export) != 0 || !strings.Contains(content, "# [[export]]\n") {
+		t.Errorf("expected a commented export example without presets, got:\n%s", content)
+	}
+}
+
+func TestInit(t *testing.T) {
+	dir := t.TempDir()
+	project := "config_version=5\n\n[application]\n\nconfig/name=\"Space Game\"\nconfig/features=PackedStringArray(\"4.2\", \"Forward Plus\")\n"
+	if err := os.WriteFile(filepath.Join(dir, "project.godot"), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+	if !Init(logger, nil) {
+		t.Fatalf("expected init to succeed")
+	}
+	content, err := os.ReadFile(internal.BuildConfigFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config := decodeBuildConfig(t, string(content)); config.Godot.Version != "4.2" {
+		t.Errorf("expected version 4.2, got %q", config.Godot.Version)
+	}
+
+	if Init(logger, nil) {
+		t.Errorf("expected init to refuse to overwrite the config")
+	}
+	if !Init(logger, []string{"--force"}) {
+		t.Errorf("expected init to overwrite the config with --force")
+	}
+}
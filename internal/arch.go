@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type TargetArch uint8
+
+const (
+	TargetArchX86_64 TargetArch = iota
+	TargetArchX86_32
+	TargetArchARM64
+	TargetArchARM32
+	TargetArchUniversal
+)
+
+func (a TargetArch) String() string {
+	switch a {
+	case TargetArchX86_64:
+		return "x86_64"
+	case TargetArchX86_32:
+		return "x86_32"
+	case TargetArchARM64:
+		return "arm64"
+	case TargetArchARM32:
+		return "arm32"
+	case TargetArchUniversal:
+		return "universal"
+	}
+	return ""
+}
+
+// ParseTargetArch returns the architecture for the given name, accepting both Godot and Go names.
+func ParseTargetArch(name string) (TargetArch, error) {
+	switch name {
+	case "x86_64", "amd64", "x64":
+		return TargetArchX86_64, nil
+	case "x86_32", "386", "x86":
+		return TargetArchX86_32, nil
+	case "arm64", "aarch64":
+		return TargetArchARM64, nil
+	case "arm32", "arm":
+		return TargetArchARM32, nil
+	case "universal":
+		return TargetArchUniversal, nil
+	}
+	return TargetArchX86_64, fmt.Errorf("unknown architecture %q", name)
+}
+
+func NewTargetArchFromRuntime(GOARCHRuntime string) TargetArch {
+	switch GOARCHRuntime {
+	case "amd64":
+		return TargetArchX86_64
+	case "386":
+		return TargetArchX86_32
+	case "arm64":
+		return TargetArchARM64
+	case "arm":
+		return TargetArchARM32
+	}
+	return TargetArchX86_64
+}
+
+func CurrentTargetArch() TargetArch {
+	return NewTargetArchFromRuntime(runtime.GOARCH)
+}
+
+// godotPlatformName returns the platform part of Godot's file names for the given target, such as
+// "linux.x86_64", "win32" or "macos.universal". Godot 3 uses "x11" and "osx" naming, and its
+// 64-bit Linux builds are the headless ones, as the x11 editor needs a display even with --no-window.
+func godotPlatformName(targetOS TargetOS, arch TargetArch, engine GodotEngine) (string, error) {
+	var godot3 bool = engine.MajorVersion() == 3
+
+	switch targetOS {
+	case TargetOSLinux:
+		if godot3 {
+			switch arch {
+			case TargetArchX86_64:
+				return "linux_headless.64", nil
+			case TargetArchX86_32:
+				return "x11.32", nil
+			}
+			break
+		}
+		if arch != TargetArchUniversal {
+			return "linux." + arch.String(), nil
+		}
+	case TargetOSWindows:
+		switch arch {
+		case TargetArchX86_64:
+			return "win64", nil
+		case TargetArchX86_32:
+			return "win32", nil
+		case TargetArchARM64:
+			if !godot3 {
+				return "windows_arm64", nil
+			}
+		}
+	case TargetOSMacOS:
+		// macOS builds are universal binaries covering every architecture
+		if godot3 {
+			return "osx.universal", nil
+		}
+		return "macos.universal", nil
+	}
+
+	return "", fmt.Errorf("Godot %s has no %s build for %s", engine.Version, arch, targetOS)
+}
@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGodotFileNames(t *testing.T) {
+	var godot3 GodotEngine = GodotEngine{Version: "3.5.3", Release: "stable"}
+	var godot3Mono GodotEngine = GodotEngine{Version: "3.5.3", Release: "stable", Mono: true}
+	var godot4 GodotEngine = GodotEngine{Version: "4.2.1", Release: "stable"}
+	var godot4Mono GodotEngine = GodotEngine{Version: "4.2.1", Release: "stable", Mono: true}
+
+	tests := []struct {
+		name     string
+		os       TargetOS
+		arch     TargetArch
+		engine   GodotEngine
+		platform string
+		fileName string
+		binary   string
+	}{
+		{"3.x linux x86_64", TargetOSLinux, TargetArchX86_64, godot3, "linux_headless.64", "Godot_v3.5.3-stable_linux_headless.64.zip", "Godot_v3.5.3-stable_linux_headless.64"},
+		{"3.x linux x86_32", TargetOSLinux, TargetArchX86_32, godot3, "x11.32", "Godot_v3.5.3-stable_x11.32.zip", "Godot_v3.5.3-stable_x11.32"},
+		{"3.x windows x86_64", TargetOSWindows, TargetArchX86_64, godot3, "win64", "Godot_v3.5.3-stable_win64.exe.zip", "Godot_v3.5.3-stable_win64.exe"},
+		{"3.x windows x86_32", TargetOSWindows, TargetArchX86_32, godot3, "win32", "Godot_v3.5.3-stable_win32.exe.zip", "Godot_v3.5.3-stable_win32.exe"},
+		{"3.x macos x86_64", TargetOSMacOS, TargetArchX86_64, godot3, "osx.universal", "Godot_v3.5.3-stable_osx.universal.zip", "Godot.app/Contents/MacOS/Godot"},
+		{"3.x macos arm64", TargetOSMacOS, TargetArchARM64, godot3, "osx.universal", "Godot_v3.5.3-stable_osx.universal.zip", "Godot.app/Contents/MacOS/Godot"},
+
+		{"3.x mono linux x86_64", TargetOSLinux, TargetArchX86_64, godot3Mono, "linux_headless.64", "Godot_v3.5.3-stable_mono_linux_headless_64.zip", "Godot_v3.5.3-stable_mono_linux_headless_64/Godot_v3.5.3-stable_mono_linux_headless.64"},
+		{"3.x mono linux x86_32", TargetOSLinux, TargetArchX86_32, godot3Mono, "x11.32", "Godot_v3.5.3-stable_mono_x11_32.zip", "Godot_v3.5.3-stable_mono_x11_32/Godot_v3.5.3-stable_mono_x11.32"},
+		{"3.x mono windows x86_64", TargetOSWindows, TargetArchX86_64, godot3Mono, "win64", "Godot_v3.5.3-stable_mono_win64.zip", "Godot_v3.5.3-stable_mono_win64/Godot_v3.5.3-stable_mono_win64.exe"},
+		{"3.x mono windows x86_32", TargetOSWindows, TargetArchX86_32, godot3Mono, "win32", "Godot_v3.5.3-stable_mono_win32.zip", "Godot_v3.5.3-stable_mono_win32/Godot_v3.5.3-stable_mono_win32.exe"},
+		{"3.x mono macos arm64", TargetOSMacOS, TargetArchARM64, godot3Mono, "osx.universal", "Godot_v3.5.3-stable_mono_osx.universal.zip", "Godot_mono.app/Contents/MacOS/Godot"},
+
+		{"4.x linux x86_64", TargetOSLinux, TargetArchX86_64, godot4, "linux.x86_64", "Godot_v4.2.1-stable_linux.x86_64.zip", "Godot_v4.2.1-stable_linux.x86_64"},
+		{"4.x linux x86_32", TargetOSLinux, TargetArchX86_32, godot4, "linux.x86_32", "Godot_v4.2.1-stable_linux.x86_32.zip", "Godot_v4.2.1-stable_linux.x86_32"},
+		{"4.x linux arm64", TargetOSLinux, TargetArchARM64, godot4, "linux.arm64", "Godot_v4.2.1-stable_linux.arm64.zip", "Godot_v4.2.1-stable_linux.arm64"},
+		{"4.x windows x86_64", TargetOSWindows, TargetArchX86_64, godot4, "win64", "Godot_v4.2.1-stable_win64.exe.zip", "Godot_v4.2.1-stable_win64.exe"},
+		{"4.x windows x86_32", TargetOSWindows, TargetArchX86_32, godot4, "win32", "Godot_v4.2.1-stable_win32.exe.zip", "Godot_v4.2.1-stable_win32.exe"},
+		{"4.x windows arm64", TargetOSWindows, TargetArchARM64, godot4, "windows_arm64", "Godot_v4.2.1-stable_windows_arm64.exe.zip", "Godot_v4.2.1-stable_windows_arm64.exe"},
+		{"4.x macos x86_64", TargetOSMacOS, TargetArchX86_64, godot4, "macos.universal", "Godot_v4.2.1-stable_macos.universal.zip", "Godot.app/Contents/MacOS/Godot"},
+		{"4.x macos arm64", TargetOSMacOS, TargetArchARM64, godot4, "macos.universal", "Godot_v4.2.1-stable_macos.universal.zip", "Godot.app/Contents/MacOS/Godot"},
+
+		{"4.x mono linux x86_64", TargetOSLinux, TargetArchX86_64, godot4Mono, "linux.x86_64", "Godot_v4.2.1-stable_mono_linux_x86_64.zip", "Godot_v4.2.1-stable_mono_linux_x86_64/Godot_v4.2.1-stable_mono_linux.x86_64"},
+		{"4.x mono linux x86_32", TargetOSLinux, TargetArchX86_32, godot4Mono, "linux.x86_32", "Godot_v4.2.1-stable_mono_linux_x86_32.zip", "Godot_v4.2.1-stable_mono_linux_x86_32/Godot_v4.2.1-stable_mono_linux.x86_32"},
+		{"4.x mono linux arm64", TargetOSLinux, TargetArchARM64, godot4Mono, "linux.arm64", "Godot_v4.2.1-stable_mono_linux_arm64.zip", "Godot_v4.2.1-stable_mono_linux_arm64/Godot_v4.2.1-stable_mono_linux.arm64"},
+		{"4.x mono windows x86_64", TargetOSWindows, TargetArchX86_64, godot4Mono, "win64", "Godot_v4.2.1-stable_mono_win64.zip", "Godot_v4.2.1-stable_mono_win64/Godot_v4.2.1-stable_mono_win64.exe"},
+		{"4.x mono windows x86_32", TargetOSWindows, TargetArchX86_32, godot4Mono, "win32", "Godot_v4.2.1-stable_mono_win32.zip", "Godot_v4.2.1-stable_mono_win32/Godot_v4.2.1-stable_mono_win32.exe"},
+		{"4.x mono windows arm64", TargetOSWindows, TargetArchARM64, godot4Mono, "windows_arm64", "Godot_v4.2.1-stable_mono_windows_arm64.zip", "Godot_v4.2.1-stable_mono_windows_arm64/Godot_v4.2.1-stable_mono_windows_arm64.exe"},
+		{"4.x mono macos x86_64", TargetOSMacOS, TargetArchX86_64, godot4Mono, "macos.universal", "Godot_v4.2.1-stable_mono_macos.universal.zip", "Godot_mono.app/Contents/MacOS/Godot"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			platform, err := godotPlatformName(test.os, test.arch, test.engine)
+			if err != nil {
+				t.Fatalf("godotPlatformName: unexpected error %s", err)
+			}
+			if platform != test.platform {
+				t.Errorf("godotPlatformName: expected %q, got %q", test.platform, platform)
+			}
+
+			fileName, err := getRemoteFileName(test.os, test.arch, test.engine)
+			if err != nil {
+				t.Fatalf("getRemoteFileName: unexpected error %s", err)
+			}
+			if fileName != test.fileName {
+				t.Errorf("getRemoteFileName: expected %q, got %q", test.fileName, fileName)
+			}
+
+			if !isTargetOSBin(test.os, test.arch, test.engine, test.binary) {
+				t.Errorf("isTargetOSBin: expected %q to be the Godot binary", test.binary)
+			}
+		})
+	}
+}
+
+func TestExportTemplatesNames(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", filepath.FromSlash("/data"))
+	t.Setenv("APPDATA", filepath.FromSlash("/appdata"))
+
+	tests := []struct {
+		name       string
+		engine     GodotEngine
+		fileName   string
+		version    string
+		engineDir  string
+		linuxDir   string
+		windowsDir string
+	}{
+		{"3.x", GodotEngine{Version: "3.5.3", Release: "stable"}, "Godot_v3.5.3-stable_export_templates.tpz", "3.5.3.stable", "3.5.3-stable", "/data/godot/templates", "/appdata/Godot/templates"},
+		{"3.x mono", GodotEngine{Version: "3.5.3", Release: "stable", Mono: true}, "Godot_v3.5.3-stable_mono_export_templates.tpz", "3.5.3.stable.mono", "3.5.3-stable-mono", "/data/godot/templates", "/appdata/Godot/templates"},
+		{"4.x", GodotEngine{Version: "4.2.1", Release: "stable"}, "Godot_v4.2.1-stable_export_templates.tpz", "4.2.1.stable", "4.2.1-stable", "/data/godot/export_templates", "/appdata/Godot/export_templates"},
+		{"4.x mono", GodotEngine{Version: "4.2.1", Release: "stable", Mono: true}, "Godot_v4.2.1-stable_mono_export_templates.tpz", "4.2.1.stable.mono", "4.2.1-stable-mono", "/data/godot/export_templates", "/appdata/Godot/export_templates"},
+		{"4.x two-part rc", GodotEngine{Version: "4.3", Release: "rc1"}, "Godot_v4.3-rc1_export_templates.tpz", "4.3.rc1", "4.3-rc1", "/data/godot/export_templates", "/appdata/Godot/export_templates"},
+		{"4.x custom mono", GodotEngine{Version: "4.2.1", Release: "stable", Mono: true, Custom: true}, "Godot_v4.2.1-stable_mono_export_templates.tpz", "4.2.1.stable.mono", "4.2.1-stable-mono-custom", "/data/godot/export_templates", "/appdata/Godot/export_templates"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if fileName := getExportTemplatesFileName(test.engine); fileName != test.fileName {
+				t.Errorf("getExportTemplatesFileName: expected %q, got %q", test.fileName, fileName)
+			}
+			if version := ExportTemplatesVersion(test.engine); version != test.version {
+				t.Errorf("ExportTemplatesVersion: expected %q, got %q", test.version, version)
+			}
+			if engineDir := test.engine.String(); engineDir != test.engineDir {
+				t.Errorf("engine directory: expected %q, got %q", test.engineDir, engineDir)
+			}
+			if dir := DefaultExportTemplatesDir(TargetOSLinux, test.engine.Version); dir != filepath.FromSlash(test.linuxDir) {
+				t.Errorf("DefaultExportTemplatesDir(linux): expected %q, got %q", test.linuxDir, dir)
+			}
+			if dir := DefaultExportTemplatesDir(TargetOSWindows, test.engine.Version); dir != filepath.FromSlash(test.windowsDir) {
+				t.Errorf("DefaultExportTemplatesDir(windows): expected %q, got %q", test.windowsDir, dir)
+			}
+		})
+	}
+}
+
+func TestGodotFileNamesUnsupported(t *testing.T) {
+	tests := []struct {
+		name   string
+		os     TargetOS
+		arch   TargetArch
+		engine GodotEngine
+	}{
+		{"3.x linux arm64", TargetOSLinux, TargetArchARM64, GodotEngine{Version: "3.5.3", Release: "stable"}},
+		{"3.x windows arm64", TargetOSWindows, TargetArchARM64, GodotEngine{Version: "3.5.3", Release: "stable"}},
+		{"4.x linux universal", TargetOSLinux, TargetArchUniversal, GodotEngine{Version: "4.2.1", Release: "stable"}},
+		{"4.x windows arm32", TargetOSWindows, TargetArchARM32, GodotEngine{Version: "4.2.1", Release: "stable"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := godotPlatformName(test.os, test.arch, test.engine); err == nil {
+				t.Errorf("godotPlatformName: expected an error")
+			}
+			if _, err := getRemoteFileName(test.os, test.arch, test.engine); err == nil {
+				t.Errorf("getRemoteFileName: expected an error")
+			}
+		})
+	}
+}
+
+func TestIsTargetOSBinRejectsOtherFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		os       TargetOS
+		arch     TargetArch
+		engine   GodotEngine
+		fileName string
+	}{
+		{"4.x windows console", TargetOSWindows, TargetArchX86_64, GodotEngine{Version: "4.2.1", Release: "stable"}, "Godot_v4.2.1-stable_win64_console.exe"},
+		{"4.x linux other arch", TargetOSLinux, TargetArchX86_64, GodotEngine{Version: "4.2.1", Release: "stable"}, "Godot_v4.2.1-stable_linux.arm64"},
+		{"4.x mono linux assembly", TargetOSLinux, TargetArchX86_64, GodotEngine{Version: "4.2.1", Release: "stable", Mono: true}, "Godot_v4.2.1-stable_mono_linux_x86_64/GodotSharp/Api/Release/GodotSharp.dll"},
+		{"3.x linux 32-bit", TargetOSLinux, TargetArchX86_64, GodotEngine{Version: "3.5.3", Release: "stable"}, "Godot_v3.5.3-stable_x11.32"},
+		{"3.x linux editor", TargetOSLinux, TargetArchX86_64, GodotEngine{Version: "3.5.3", Release: "stable"}, "Godot_v3.5.3-stable_x11.64"},
+		{"4.x macos outside bundle", TargetOSMacOS, TargetArchARM64, GodotEngine{Version: "4.2.1", Release: "stable"}, "Godot.app/Contents/Info.plist"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if isTargetOSBin(test.os, test.arch, test.engine, test.fileName) {
+				t.Errorf("expected %q not to be the Godot binary", test.fileName)
+			}
+		})
+	}
+}
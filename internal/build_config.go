@@ -1,8 +1,12 @@
 package internal
 
 import (
+	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/yeslayla/godot-build-tools/logging"
@@ -11,33 +15,213 @@ import (
 const defaultGodotVersion = "4.1.3"
 const defaultGodotRelease = "stable"
 
+const BuildConfigFile = ".godot-build.toml"
+
 type BuildConfig struct {
-	Godot BuildConfigGodot `toml:"godot"`
+	Godot     BuildConfigGodot      `toml:"godot"`
+	Cache     BuildConfigCache      `toml:"cache"`
+	Download  BuildConfigDownload   `toml:"download"`
+	Sources   []BuildConfigSource   `toml:"source"`
+	Export    []BuildConfigExport   `toml:"export"`
+	Pipelines []BuildConfigPipeline `toml:"pipeline"`
 }
 
 type BuildConfigGodot struct {
+	// Version is an exact version such as "4.2.1" or "4.3", or a constraint such as "~4.2",
+	// ">=4.1 <4.3" or "latest"
 	Version string `toml:"version"`
+	// Release is a release such as "stable" or "rc1", or the newest of a channel such as "latest-rc"
 	Release string `toml:"release"`
+	// Manifest is a JSON list of versions used to resolve version constraints offline
+	Manifest string `toml:"manifest"`
+	// Mono selects the .NET build of Godot, needed for C# projects
+	Mono bool `toml:"mono"`
+	// Arch overrides the architecture of the Godot build, such as "x86_64" or "arm64"
+	Arch string `toml:"arch"`
+	// Checksum is the expected SHA-512 of the Godot package, for mirrors without a SHA512-SUMS.txt
+	Checksum string `toml:"checksum"`
+	// TemplatesChecksum is the expected SHA-512 of the export templates package
+	TemplatesChecksum string `toml:"templates_checksum"`
+	// Source installs a custom build of Godot instead of an official release,
+	// such as "path:/opt/godot-custom.zip" or "url:https://example.com/godot-custom.zip"
+	Source string `toml:"source"`
+	// Binary is the path of the Godot binary in the Source package, such as
+	// "bin/godot.linuxbsd.editor.x86_64". It defaults to the package's only executable
+	Binary string `toml:"binary"`
+	// TemplatesSource installs custom export templates, in the same form as Source
+	TemplatesSource string `toml:"templates_source"`
+	// SourceHeaders are sent when downloading Source and TemplatesSource URLs, values may
+	// reference environment variables such as "Bearer ${BUILDS_TOKEN}"
+	SourceHeaders map[string]string `toml:"source_headers"`
+	// VerifyVersion checks that `godot --version` matches the configured version after installing
+	VerifyVersion bool `toml:"verify_version"`
+}
+
+type BuildConfigCache struct {
+	// Dir is where downloads are cached, the GBT_CACHE_DIR environment variable takes precedence
+	Dir string `toml:"dir"`
+}
+
+type BuildConfigDownload struct {
+	// Timeout limits how long a download may take including retries, as a duration such as "30m"
+	Timeout string `toml:"timeout"`
+	// Retries is the number of times a failed download is retried
+	Retries *int `toml:"retries"`
+	// Proxy is the URL of an HTTP proxy, the HTTPS_PROXY environment variable is used by default
+	Proxy string `toml:"proxy"`
+	// CABundle is the path of a PEM file with extra CA certificates to trust
+	CABundle string `toml:"ca_bundle"`
+	// Offline refuses network access, packages must be vendored with `gbt fetch` or cached
+	Offline bool `toml:"offline"`
+	// VendorDir is where `gbt fetch` vendors packages, defaulting to "vendor/godot"
+	VendorDir string `toml:"vendor_dir"`
+}
+
+type BuildConfigSource struct {
+	// Type is the URL layout of the source, "github", "tuxfamily" or "custom"
+	Type string `toml:"type"`
+	// URL overrides the default location of the source, or is a template with {version},
+	// {release} and {file} placeholders for custom sources
+	URL  string `toml:"url"`
+	Name string `toml:"name"`
+	// Headers are sent with every request to the source, values may reference environment
+	// variables such as "Bearer ${MIRROR_TOKEN}"
+	Headers map[string]string `toml:"headers"`
+}
+
+// options returns the options for creating a download source from the config.
+func (c BuildConfigSource) options() *DownloadSourceOptions {
+	headers := http.Header{}
+	for name, value := range c.Headers {
+		headers.Set(name, value)
+	}
+
+	return &DownloadSourceOptions{
+		URL:     c.URL,
+		Name:    c.Name,
+		Headers: headers,
+	}
+}
+
+type BuildConfigExport struct {
+	Preset string `toml:"preset"`
+	Path   string `toml:"path"`
+	Type   string `toml:"type"`
+	// Timeout is the longest the export may run for, as a duration such as "30m"
+	Timeout string `toml:"timeout"`
+}
+
+type BuildConfigPipeline struct {
+	// Name selects the pipeline with --pipeline
+	Name  string                    `toml:"name"`
+	Steps []BuildConfigPipelineStep `toml:"step"`
+}
+
+type BuildConfigPipelineStep struct {
+	Name string `toml:"name"`
+	// Uses runs a built-in step, along with any steps it depends on that haven't run yet
+	Uses string `toml:"uses"`
+	// Run is a shell command to run, with Env added to its environment in WorkingDir
+	Run        string            `toml:"run"`
+	Env        map[string]string `toml:"env"`
+	WorkingDir string            `toml:"working_dir"`
+	// If skips the step unless the condition holds, such as "os == 'linux' && branch == 'main'"
+	If string `toml:"if"`
+	// ContinueOnError keeps the pipeline going when the step fails
+	ContinueOnError bool `toml:"continue_on_error"`
+}
+
+// Pipeline returns the pipeline with the given name.
+func (c BuildConfig) Pipeline(name string) (BuildConfigPipeline, bool) {
+	for _, pipeline := range c.Pipelines {
+		if pipeline.Name == name {
+			return pipeline, true
+		}
+	}
+	return BuildConfigPipeline{}, false
+}
+
+// TargetArch returns the configured architecture, or the current architecture if none is configured.
+func (c BuildConfigGodot) TargetArch() TargetArch {
+	if c.Arch == "" {
+		return CurrentTargetArch()
+	}
+	arch, _ := ParseTargetArch(c.Arch)
+	return arch
+}
+
+// Engine returns the Godot engine build described by the config.
+func (c BuildConfigGodot) Engine() GodotEngine {
+	return GodotEngine{
+		Version: c.Version,
+		Release: c.Release,
+		Mono:    c.Mono,
+	}
+}
+
+// EditorEngine returns the engine the Godot editor is installed as. Custom builds share their
+// version with the official release, so they are installed apart from it.
+func (c BuildConfigGodot) EditorEngine() GodotEngine {
+	engine := c.Engine()
+	engine.Custom = c.Source != ""
+	return engine
+}
+
+// SourceHTTPHeaders returns the headers sent when downloading custom packages.
+func (c BuildConfigGodot) SourceHTTPHeaders() http.Header {
+	headers := http.Header{}
+	for name, value := range c.SourceHeaders {
+		headers.Set(name, value)
+	}
+	return headers
+}
+
+// DownloaderOptions returns the options for creating a downloader from the config.
+func (c BuildConfig) DownloaderOptions() *DownloaderOptions {
+	options := &DownloaderOptions{
+		CacheDir:  c.Cache.Dir,
+		Proxy:     c.Download.Proxy,
+		CABundle:  c.Download.CABundle,
+		Offline:   c.Download.Offline,
+		VendorDir: c.Download.VendorDir,
+	}
+
+	// Sources are validated when the config is loaded
+	for _, source := range c.Sources {
+		downloadSource, _ := NewDownloadSource(source.Type, source.options())
+		options.Sources = append(options.Sources, downloadSource)
+	}
+
+	// Durations are validated when the config is loaded
+	options.Timeout, _ = time.ParseDuration(c.Download.Timeout)
+
+	if c.Download.Retries != nil {
+		options.Retries = *c.Download.Retries
+		if options.Retries == 0 {
+			options.Retries = -1
+		}
+	}
+
+	return options
 }
 
 func LoadBuildConfig(logger logging.Logger) BuildConfig {
 	config := BuildConfig{}
 
-	content, err := ioutil.ReadFile(".godot-build.toml")
+	content, err := ioutil.ReadFile(BuildConfigFile)
 	if err != nil {
 		if os.IsNotExist(err) {
 			logger.Errorf("Build config not found, please run `gbt init`")
-			os.Exit(1)
-		} else {
-			logger.Errorf("Failed to read build config: %s", err)
+			os.Exit(ExitCodeConfig)
 		}
-		return config
+		logger.Errorf("Failed to read build config: %s", err)
+		os.Exit(ExitCodeConfig)
 	}
 
 	_, err = toml.Decode(string(content), &config)
 	if err != nil {
 		logger.Errorf("Failed to parse build config: %s", err)
-		return config
+		os.Exit(ExitCodeConfig)
 	}
 
 	if config.Godot.Release == "" {
@@ -49,6 +233,125 @@ func LoadBuildConfig(logger logging.Logger) BuildConfig {
 		logger.Warnf("Godot version not specified, defaulting to %s", defaultGodotVersion)
 		config.Godot.Version = defaultGodotVersion
 	}
+	if exact, ok := ExactVersion(config.Godot.Version); ok {
+		config.Godot.Version = exact
+	}
+
+	if config.Godot.Arch != "" {
+		if _, err := ParseTargetArch(config.Godot.Arch); err != nil {
+			logger.Errorf("Invalid Godot architecture: %s", err)
+			os.Exit(ExitCodeConfig)
+		}
+	}
+
+	if err := validateCustomSource(config.Godot.Source, config.Godot.Checksum, "checksum"); err != nil {
+		logger.Errorf("Invalid Godot source: %s", err)
+		os.Exit(ExitCodeConfig)
+	}
+	if err := validateCustomSource(config.Godot.TemplatesSource, config.Godot.TemplatesChecksum, "templates_checksum"); err != nil {
+		logger.Errorf("Invalid export templates source: %s", err)
+		os.Exit(ExitCodeConfig)
+	}
+	if config.Godot.Binary != "" && (config.Godot.Source == "" || !filepath.IsLocal(filepath.FromSlash(config.Godot.Binary))) {
+		logger.Errorf("Invalid Godot binary %q: it must be a path within the package set in `source`", config.Godot.Binary)
+		os.Exit(ExitCodeConfig)
+	}
+	if config.Godot.Source != "" && NeedsVersionResolution(config.Godot.Engine()) {
+		logger.Errorf("Custom Godot builds need an exact version and release, not %s-%s", config.Godot.Version, config.Godot.Release)
+		os.Exit(ExitCodeConfig)
+	}
+
+	if config.Download.Timeout != "" {
+		if _, err := time.ParseDuration(config.Download.Timeout); err != nil {
+			logger.Errorf("Invalid download timeout: %s", err)
+			os.Exit(ExitCodeConfig)
+		}
+	}
+
+	if config.Download.Proxy != "" || config.Download.CABundle != "" {
+		if _, err := newHTTPClient(config.Download.Proxy, config.Download.CABundle); err != nil {
+			logger.Errorf("Invalid download settings: %s", err)
+			os.Exit(ExitCodeConfig)
+		}
+	}
+
+	if err := validatePipelines(config.Pipelines); err != nil {
+		logger.Errorf("Invalid pipeline: %s", err)
+		os.Exit(ExitCodeConfig)
+	}
+
+	for name, value := range config.Godot.SourceHeaders {
+		config.Godot.SourceHeaders[name] = expandSecret(value, logger)
+	}
+	for _, source := range config.Sources {
+		for name, value := range source.Headers {
+			source.Headers[name] = expandSecret(value, logger)
+		}
+		if _, err := NewDownloadSource(source.Type, source.options()); err != nil {
+			logger.Errorf("Invalid download source: %s", err)
+			os.Exit(ExitCodeConfig)
+		}
+	}
 
 	return config
 }
+
+// validatePipelines checks that pipelines have unique names and that each of their steps
+// either uses a built-in step or runs a command, with `env` and `working_dir` only set for
+// commands. Built-in step names are checked when planning.
+func validatePipelines(pipelines []BuildConfigPipeline) error {
+	names := make(map[string]bool)
+	for _, pipeline := range pipelines {
+		if pipeline.Name == "" {
+			return fmt.Errorf("pipelines must have a name")
+		}
+		if names[pipeline.Name] {
+			return fmt.Errorf("more than one pipeline is named %q", pipeline.Name)
+		}
+		names[pipeline.Name] = true
+
+		for i, step := range pipeline.Steps {
+			if (step.Uses == "") == (step.Run == "") {
+				return fmt.Errorf("step %d of %s must have either `uses` or `run`", i+1, pipeline.Name)
+			}
+			if step.Uses != "" && (len(step.Env) > 0 || step.WorkingDir != "") {
+				return fmt.Errorf("step %d of %s uses %s, `env` and `working_dir` only apply to `run` steps", i+1, pipeline.Name, step.Uses)
+			}
+			if _, err := ParseCondition(step.If); err != nil {
+				return fmt.Errorf("step %d of %s: %s", i+1, pipeline.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCustomSource checks a custom package source, which must come with a checksum when it is a URL.
+func validateCustomSource(source string, checksum string, checksumKey string) error {
+	if source == "" {
+		return nil
+	}
+
+	location, err := ParsePackageLocation(source)
+	if err != nil {
+		return err
+	}
+	if location.Kind == PackageLocationURL && checksum == "" {
+		return fmt.Errorf("set `%s` in [godot] to download %s", checksumKey, location.Location)
+	}
+	return nil
+}
+
+// expandSecret replaces environment variable references in a config value, masking the
+// variables' values so tokens never show up in the logs.
+func expandSecret(value string, logger logging.Logger) string {
+	return os.Expand(value, func(name string) string {
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			logger.Warnf("Environment variable %s is not set", name)
+		}
+		if secret != "" {
+			logger.Mask(secret)
+		}
+		return secret
+	})
+}
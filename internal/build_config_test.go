@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loadTestConfig loads a build config with the given content from a temporary working directory.
+func loadTestConfig(t *testing.T, content string) BuildConfig {
+	t.Helper()
+	return loadTestConfigWithLogger(t, content, &recordingLogger{})
+}
+
+// loadTestConfigWithLogger loads a build config like loadTestConfig, logging to the given logger.
+func loadTestConfigWithLogger(t *testing.T, content string, logger *recordingLogger) BuildConfig {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, BuildConfigFile), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write build config: %s", err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %s", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to change working directory: %s", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	return LoadBuildConfig(logger)
+}
+
+func TestLoadBuildConfigCustomBuildVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"4.3", "4.3"},
+		{"=4.3", "4.3"},
+		{"4.2.1", "4.2.1"},
+	}
+
+	for _, test := range tests {
+		config := loadTestConfig(t, `
+[godot]
+version = "`+test.version+`"
+source = "path:godot-custom.zip"
+`)
+		if config.Godot.Version != test.expected {
+			t.Errorf("%s: expected version %s, got %s", test.version, test.expected, config.Godot.Version)
+		}
+		if engine := config.Godot.EditorEngine(); engine.String() != test.expected+"-stable-custom" {
+			t.Errorf("%s: expected engine %s-stable-custom, got %s", test.version, test.expected, engine)
+		}
+	}
+}
+
+func TestLoadBuildConfigDefaultVersion(t *testing.T) {
+	config := loadTestConfig(t, "[godot]\n")
+	if !IsExactVersion(config.Godot.Version) || config.Godot.Release != "stable" {
+		t.Errorf("expected a pinned default version, got %s-%s", config.Godot.Version, config.Godot.Release)
+	}
+	if NeedsVersionResolution(config.Godot.Engine()) {
+		t.Errorf("expected the default version to install without listing versions")
+	}
+}
+
+func TestValidatePipelines(t *testing.T) {
+	tests := []struct {
+		name      string
+		pipelines []BuildConfigPipeline
+		valid     bool
+	}{
+		{"uses and run", []BuildConfigPipeline{{Name: "release", Steps: []BuildConfigPipelineStep{
+			{Uses: "export"},
+			{Run: "zip -r game.zip build", Env: map[string]string{"LEVEL": "9"}, WorkingDir: "build", If: "branch == 'release/*'"},
+		}}}, true},
+		{"no name", []BuildConfigPipeline{{Steps: []BuildConfigPipelineStep{{Uses: "export"}}}}, false},
+		{"duplicate name", []BuildConfigPipeline{{Name: "release"}, {Name: "release"}}, false},
+		{"neither uses nor run", []BuildConfigPipeline{{Name: "release", Steps: []BuildConfigPipelineStep{{Name: "Nothing"}}}}, false},
+		{"both uses and run", []BuildConfigPipeline{{Name: "release", Steps: []BuildConfigPipelineStep{{Uses: "export", Run: "true"}}}}, false},
+		{"uses with env", []BuildConfigPipeline{{Name: "release", Steps: []BuildConfigPipelineStep{{Uses: "export", Env: map[string]string{"LEVEL": "9"}}}}}, false},
+		{"uses with working_dir", []BuildConfigPipeline{{Name: "release", Steps: []BuildConfigPipelineStep{{Uses: "export", WorkingDir: "build"}}}}, false},
+		{"invalid condition", []BuildConfigPipeline{{Name: "release", Steps: []BuildConfigPipelineStep{{Uses: "export", If: "os = 'linux'"}}}}, false},
+	}
+
+	for _, test := range tests {
+		err := validatePipelines(test.pipelines)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
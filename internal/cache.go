@@ -0,0 +1,205 @@
+package internal
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+// CacheDirEnv is the environment variable that overrides the cache directory.
+const CacheDirEnv = "GBT_CACHE_DIR"
+
+const cacheLockSuffix = ".lock"
+const cachePartialSuffix = ".part"
+const cacheLockTimeout = 30 * time.Minute
+
+// ResolveCacheDir returns the cache directory to use. The GBT_CACHE_DIR environment
+// variable takes precedence over the configured directory, which takes precedence over
+// the user's cache directory.
+func ResolveCacheDir(configDir string) string {
+	if dir := os.Getenv(CacheDirEnv); dir != "" {
+		return dir
+	}
+	if configDir != "" {
+		return configDir
+	}
+	return DefaultCacheDir()
+}
+
+// DefaultCacheDir returns the user's cache directory for Godot Build Tools, following XDG_CACHE_HOME on Linux.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "godot-build-tools")
+	}
+	return filepath.Join(dir, "godot-build-tools")
+}
+
+// CacheKey identifies a downloaded file in the cache.
+type CacheKey struct {
+	Engine   GodotEngine
+	OS       TargetOS
+	Checksum string
+	FileName string
+}
+
+// CacheEntry describes a file stored in the cache.
+type CacheEntry struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Cache is a content-addressed store of downloaded Godot packages and export templates.
+type Cache struct {
+	dir    string
+	logger logging.Logger
+}
+
+// NewCache creates a new cache in the given directory, see ResolveCacheDir.
+func NewCache(dir string, logger logging.Logger) *Cache {
+	return &Cache{
+		dir:    ResolveCacheDir(dir),
+		logger: logger,
+	}
+}
+
+// Dir returns the cache's root directory.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Path returns where the file for the given key is stored in the cache.
+func (c *Cache) Path(key CacheKey) string {
+	var checksum string = strings.ToLower(key.Checksum)
+	if len(checksum) > 32 {
+		checksum = checksum[:32]
+	}
+
+	entry := fmt.Sprintf("%s-%s", key.Engine, key.OS)
+	return filepath.Join(c.dir, entry, checksum, key.FileName)
+}
+
+// Lookup returns the path of the cached file for the given key, if it is present and
+// still matches its checksum. Corrupted entries are removed.
+func (c *Cache) Lookup(key CacheKey) (string, bool) {
+	cachePath := c.Path(key)
+	if _, err := os.Stat(cachePath); err != nil {
+		return "", false
+	}
+
+	actual, err := FileSHA512(cachePath)
+	if err != nil || verifyChecksum(key.FileName, key.Checksum, actual) != nil {
+		c.logger.Warnf("Removing corrupted cache entry %s", cachePath)
+		_ = os.Remove(cachePath)
+		return "", false
+	}
+
+	// Touch the entry so pruning by age keeps recently used files
+	now := time.Now()
+	_ = os.Chtimes(cachePath, now, now)
+
+	return cachePath, true
+}
+
+// Lock acquires an exclusive lock on the cache entry for the given key, waiting for
+// other processes holding it. The returned function releases the lock. Locks are held
+// with the operating system, so a killed process never leaves one behind.
+func (c *Cache) Lock(key CacheKey) (func(), error) {
+	cachePath := c.Path(key)
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %s", err)
+	}
+
+	lock, err := waitFileLock(cachePath+cacheLockSuffix, cacheLockTimeout, func() {
+		c.logger.Infof("Waiting for another job to finish downloading %s", key.FileName)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lock.release, nil
+}
+
+// List returns every file stored in the cache, ordered by path.
+func (c *Cache) List() ([]CacheEntry, error) {
+	var entries []CacheEntry = make([]CacheEntry, 0)
+
+	err := filepath.WalkDir(c.dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && filePath == c.dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(filePath, cacheLockSuffix) || strings.HasSuffix(filePath, cachePartialSuffix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, CacheEntry{
+			Path:    filePath,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries, nil
+}
+
+// Prune removes cached files that haven't been used for longer than the given age.
+// Entries locked by a running job are skipped. The removed entries are returned.
+func (c *Cache) Prune(olderThan time.Duration) ([]CacheEntry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []CacheEntry = make([]CacheEntry, 0)
+	for _, entry := range entries {
+		if time.Since(entry.ModTime) < olderThan {
+			continue
+		}
+		lock, err := tryFileLock(entry.Path + cacheLockSuffix)
+		if err != nil {
+			return removed, err
+		}
+		if lock == nil {
+			c.logger.Debugf("Skipping locked cache entry %s", entry.Path)
+			continue
+		}
+
+		err = os.Remove(entry.Path)
+		lock.release()
+		if err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %s", entry.Path, err)
+		}
+		removed = append(removed, entry)
+
+		// Clean up the now empty checksum and version directories
+		dir := filepath.Dir(entry.Path)
+		for dir != c.dir && strings.HasPrefix(dir, c.dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCacheLock(t *testing.T) {
+	t.Setenv(CacheDirEnv, "")
+	cache := NewCache(t.TempDir(), &recordingLogger{})
+	key := CacheKey{
+		Engine:   GodotEngine{Version: "4.2.1", Release: "stable"},
+		OS:       TargetOSLinux,
+		Checksum: "abcdef",
+		FileName: "Godot_v4.2.1-stable_linux.x86_64.zip",
+	}
+	lockPath := cache.Path(key) + cacheLockSuffix
+
+	unlock, err := cache.Lock(key)
+	if err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+
+	other, err := tryFileLock(lockPath)
+	if err != nil {
+		t.Fatalf("failed to try the lock: %s", err)
+	}
+	if other != nil {
+		other.release()
+		t.Fatalf("expected the lock to be held")
+	}
+
+	unlock()
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("expected the lock file to be removed, got %v", err)
+	}
+
+	other, err = tryFileLock(lockPath)
+	if err != nil {
+		t.Fatalf("failed to try the lock: %s", err)
+	}
+	if other == nil {
+		t.Fatalf("expected the lock to be free after release")
+	}
+	other.release()
+}
+
+func TestCachePruneSkipsLockedEntries(t *testing.T) {
+	t.Setenv(CacheDirEnv, "")
+	cache := NewCache(t.TempDir(), &recordingLogger{})
+
+	var keys []CacheKey
+	for _, checksum := range []string{"aaaa", "bbbb"} {
+		key := CacheKey{
+			Engine:   GodotEngine{Version: "4.2.1", Release: "stable"},
+			OS:       TargetOSLinux,
+			Checksum: checksum,
+			FileName: "Godot_v4.2.1-stable_linux.x86_64.zip",
+		}
+		unlock, err := cache.Lock(key)
+		if err != nil {
+			t.Fatalf("failed to lock: %s", err)
+		}
+		if err := os.WriteFile(cache.Path(key), []byte(checksum), 0644); err != nil {
+			t.Fatalf("failed to write cache entry: %s", err)
+		}
+		unlock()
+
+		old := time.Now().Add(-48 * time.Hour)
+		if err := os.Chtimes(cache.Path(key), old, old); err != nil {
+			t.Fatalf("failed to age cache entry: %s", err)
+		}
+		keys = append(keys, key)
+	}
+
+	unlock, err := cache.Lock(keys[0])
+	if err != nil {
+		t.Fatalf("failed to lock: %s", err)
+	}
+	defer unlock()
+
+	removed, err := cache.Prune(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("failed to prune: %s", err)
+	}
+	if len(removed) != 1 || removed[0].Path != cache.Path(keys[1]) {
+		t.Errorf("expected only the unlocked entry to be removed, got %v", removed)
+	}
+	if _, err := os.Stat(cache.Path(keys[0])); err != nil {
+		t.Errorf("expected the locked entry to be kept: %s", err)
+	}
+}
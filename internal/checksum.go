@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"bufio"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const checksumsFileName = "SHA512-SUMS.txt"
+
+// ParseChecksums parses a sha512sum-style checksums file into a map of file name to hash.
+func ParseChecksums(content string) map[string]string {
+	var checksums map[string]string = make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// sha512sum marks files hashed in binary mode with a leading '*'
+		checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+
+	return checksums
+}
+
+// FileSHA512 returns the hex-encoded SHA-512 hash of a file.
+func FileSHA512(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha512.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ChecksumError is returned when a file doesn't match its expected checksum.
+type ChecksumError struct {
+	FileName string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s: expected %s, got %s", e.FileName, e.Expected, e.Actual)
+}
+
+// verifyChecksum returns a ChecksumError if the actual hash doesn't match the expected one.
+func verifyChecksum(fileName string, expected string, actual string) error {
+	if !strings.EqualFold(strings.TrimSpace(expected), actual) {
+		return &ChecksumError{FileName: fileName, Expected: expected, Actual: actual}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseChecksums(t *testing.T) {
+	content := strings.Join([]string{
+		"AB12CD  Godot_v4.2.1-stable_linux.x86_64.zip",
+		"ef34  *Godot_v4.2.1-stable_export_templates.tpz",
+		"",
+		"5678\tGodot_v4.2.1-stable_win64.exe.zip\r",
+		"# not a checksum",
+		"9abc",
+		"def0  file name with spaces.zip",
+		"",
+	}, "\n")
+
+	expected := map[string]string{
+		"Godot_v4.2.1-stable_linux.x86_64.zip":     "ab12cd",
+		"Godot_v4.2.1-stable_export_templates.tpz": "ef34",
+		"Godot_v4.2.1-stable_win64.exe.zip":        "5678",
+	}
+
+	checksums := ParseChecksums(content)
+	if len(checksums) != len(expected) {
+		t.Errorf("expected %d checksums, got %v", len(expected), checksums)
+	}
+	for name, checksum := range expected {
+		if checksums[name] != checksum {
+			t.Errorf("%s: expected %q, got %q", name, checksum, checksums[name])
+		}
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	tests := []struct {
+		expected string
+		actual   string
+		valid    bool
+	}{
+		{"ab12cd", "ab12cd", true},
+		{"AB12CD", "ab12cd", true},
+		{" ab12cd\n", "ab12cd", true},
+		{"ab12ce", "ab12cd", false},
+		{"", "ab12cd", false},
+	}
+
+	for _, test := range tests {
+		err := verifyChecksum("godot.zip", test.expected, test.actual)
+		var checksumErr *ChecksumError
+		if test.valid && err != nil {
+			t.Errorf("%q: unexpected error: %s", test.expected, err)
+		}
+		if !test.valid && !errors.As(err, &checksumErr) {
+			t.Errorf("%q: expected a checksum error, got %v", test.expected, err)
+		}
+	}
+}
+
+func TestDownloadReleaseFileChecksums(t *testing.T) {
+	const fileName = "Godot_v4.2.1-stable_linux.x86_64.zip"
+	otherChecksum := strings.Repeat("0", 128)
+
+	tests := []struct {
+		name     string
+		sums     string
+		checksum string
+		// requests are the files the sources are asked for
+		requests []string
+		err      string
+	}{
+		{"from SHA512-SUMS", testPackageChecksum() + "  " + fileName + "\n", "", []string{checksumsFileName, fileName}, ""},
+		{"missing entry", otherChecksum + "  Godot_v4.2.1-stable_win64.exe.zip\n", "", []string{checksumsFileName}, "SHA512-SUMS.txt has no entry for " + fileName},
+		{"override", otherChecksum + "  " + fileName + "\n", testPackageChecksum(), []string{fileName}, ""},
+		{"override mismatch", testPackageChecksum() + "  " + fileName + "\n", otherChecksum, []string{fileName}, "checksum mismatch"},
+	}
+
+	for _, test := range tests {
+		var mu sync.Mutex
+		var requests []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requests = append(requests, strings.TrimPrefix(r.URL.Path, "/"))
+			mu.Unlock()
+
+			switch r.URL.Path {
+			case "/" + checksumsFileName:
+				_, _ = w.Write([]byte(test.sums))
+			case "/" + fileName:
+				_, _ = w.Write(testPackage)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+
+		downloader := newTestDownloader(t, newTestSource(t, "mirror", server))
+		packagePath, err := downloader.downloadReleaseFile(TargetOSLinux, GodotEngine{Version: "4.2.1", Release: "stable"}, fileName, test.checksum)
+		server.Close()
+
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", test.name, err)
+			} else {
+				assertTestPackage(t, packagePath)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected error to contain %q, got %v", test.name, test.err, err)
+		}
+
+		if strings.Join(requests, ",") != strings.Join(test.requests, ",") {
+			t.Errorf("%s: expected requests for %v, got %v", test.name, test.requests, requests)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// conditionKeys are the values a condition can test.
+var conditionKeys = []string{"os", "arch", "branch"}
+
+// Condition is a parsed `if` condition of a pipeline step, such as
+// "os == 'linux' && branch == 'main'" or "branch == 'release/*' || branch == 'main'".
+// && binds tighter than ||, and values may be quoted glob patterns, in which * doesn't match
+// a "/", so "release/*" matches "release/1.0" but not "release/1.0/hotfix".
+type Condition struct {
+	// any holds the alternatives, each of which matches if all of its terms match
+	any [][]conditionTerm
+}
+
+// conditionTerm is a single comparison of a condition, such as "os == 'linux'".
+type conditionTerm struct {
+	key     string
+	negate  bool
+	pattern string
+}
+
+// ParseCondition parses a pipeline step condition. An empty condition always matches.
+func ParseCondition(expr string) (Condition, error) {
+	var condition Condition
+	if strings.TrimSpace(expr) == "" {
+		return condition, nil
+	}
+
+	alternatives, err := splitCondition(expr, "||")
+	if err != nil {
+		return Condition{}, err
+	}
+	for _, alternative := range alternatives {
+		var terms []conditionTerm
+		conditionTerms, err := splitCondition(alternative, "&&")
+		if err != nil {
+			return Condition{}, err
+		}
+		for _, term := range conditionTerms {
+			parsed, err := parseConditionTerm(strings.TrimSpace(term))
+			if err != nil {
+				return Condition{}, err
+			}
+			terms = append(terms, parsed)
+		}
+		condition.any = append(condition.any, terms)
+	}
+
+	return condition, nil
+}
+
+// splitCondition splits a condition at each operator outside of a quoted value.
+func splitCondition(expr string, operator string) ([]string, error) {
+	var parts []string
+	var quote byte
+	var start int
+	for i := 0; i < len(expr); i++ {
+		switch {
+		case quote != 0:
+			if expr[i] == quote {
+				quote = 0
+			}
+		case expr[i] == '\'' || expr[i] == '"':
+			quote = expr[i]
+		case strings.HasPrefix(expr[i:], operator):
+			parts = append(parts, expr[start:i])
+			start = i + len(operator)
+			i += len(operator) - 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in condition %q", expr)
+	}
+	return append(parts, expr[start:]), nil
+}
+
+// parseConditionTerm parses a comparison such as "branch != 'main'".
+func parseConditionTerm(term string) (conditionTerm, error) {
+	var parsed conditionTerm
+	// The operator is the first one in the term, a quoted value may contain either
+	key, value, found := strings.Cut(term, "==")
+	if negated, negatedValue, ok := strings.Cut(term, "!="); ok && (!found || len(negated) < len(key)) {
+		key, value, found = negated, negatedValue, true
+		parsed.negate = true
+	}
+	if !found {
+		return parsed, fmt.Errorf("invalid condition %q, expected a comparison such as \"os == 'linux'\"", term)
+	}
+
+	parsed.key = strings.TrimSpace(key)
+	if !containsString(conditionKeys, parsed.key) {
+		return parsed, fmt.Errorf("unknown condition key %q, expected one of %s", parsed.key, strings.Join(conditionKeys, ", "))
+	}
+
+	pattern, err := unquoteConditionValue(strings.TrimSpace(value))
+	if err != nil {
+		return parsed, err
+	}
+	parsed.pattern = pattern
+	if _, err := path.Match(parsed.pattern, ""); err != nil {
+		return parsed, fmt.Errorf("invalid pattern %q in condition: %s", parsed.pattern, err)
+	}
+
+	return parsed, nil
+}
+
+// unquoteConditionValue returns the value of a comparison, which is either quoted with
+// matching single or double quotes or a bare word.
+func unquoteConditionValue(value string) (string, error) {
+	if value == "" {
+		return "", fmt.Errorf("missing value in condition, expected a value such as 'linux'")
+	}
+
+	quote := value[0]
+	if quote != '\'' && quote != '"' {
+		if strings.ContainsAny(value, "'\" \t") {
+			return "", fmt.Errorf("invalid value %q in condition, quote values such as 'release/*'", value)
+		}
+		return value, nil
+	}
+	if len(value) < 2 || value[len(value)-1] != quote || strings.IndexByte(value[1:len(value)-1], quote) >= 0 {
+		return "", fmt.Errorf("invalid value %s in condition, quotes don't match", value)
+	}
+	return value[1 : len(value)-1], nil
+}
+
+// Matches returns true if the condition holds for the given values of os, arch and branch.
+func (c Condition) Matches(values map[string]string) bool {
+	if len(c.any) == 0 {
+		return true
+	}
+
+	for _, terms := range c.any {
+		var matches bool = true
+		for _, term := range terms {
+			matched, _ := path.Match(term.pattern, values[term.key])
+			if matched == term.negate {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
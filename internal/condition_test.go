@@ -0,0 +1,80 @@
+package internal
+
+import "testing"
+
+func TestParseCondition(t *testing.T) {
+	linuxMain := map[string]string{"os": "linux", "arch": "x86_64", "branch": "main"}
+	windowsMain := map[string]string{"os": "windows", "arch": "x86_64", "branch": "main"}
+	windowsRelease := map[string]string{"os": "windows", "arch": "x86_64", "branch": "release/1.0"}
+	windowsHotfix := map[string]string{"os": "windows", "arch": "x86_64", "branch": "release/1.0/hotfix"}
+	linuxPipes := map[string]string{"os": "linux", "arch": "x86_64", "branch": "a||b&&c"}
+
+	tests := []struct {
+		expr    string
+		values  map[string]string
+		matches bool
+	}{
+		{"", windowsMain, true},
+		{"   ", windowsMain, true},
+		{"os == 'linux'", linuxMain, true},
+		{"os == 'linux'", windowsMain, false},
+		{"os != 'linux'", windowsMain, true},
+		{`os == "linux"`, linuxMain, true},
+		{"os == linux", linuxMain, true},
+		{"os=='linux'", linuxMain, true},
+
+		// && binds tighter than ||
+		{"os == 'linux' && branch == 'dev' || branch == 'main'", windowsMain, true},
+		{"os == 'linux' && branch == 'dev' || branch == 'main'", linuxMain, true},
+		{"branch == 'main' || os == 'linux' && arch == 'arm64'", windowsMain, true},
+		{"branch == 'dev' || os == 'linux' && arch == 'arm64'", linuxMain, false},
+		{"branch == 'dev' || os == 'linux' && arch == 'x86_64'", linuxMain, true},
+
+		// * doesn't match a /
+		{"branch == 'release/*'", windowsRelease, true},
+		{"branch == 'release/*'", windowsHotfix, false},
+		{"branch == 'release/*/*'", windowsHotfix, true},
+		{"branch == 'release/*'", windowsMain, false},
+		{"branch != 'release/*'", windowsMain, true},
+		{"branch == 'ma?n'", windowsMain, true},
+
+		// Operators in quoted values are part of the value
+		{"branch == 'a||b&&c'", linuxPipes, true},
+		{"branch == 'a||b&&c' && os == 'linux'", linuxPipes, true},
+		{`branch != "a==b"`, linuxMain, true},
+		{`branch == "a!=b"`, linuxMain, false},
+	}
+
+	for _, test := range tests {
+		condition, err := ParseCondition(test.expr)
+		if err != nil {
+			t.Errorf("ParseCondition(%q): unexpected error: %s", test.expr, err)
+			continue
+		}
+		if matches := condition.Matches(test.values); matches != test.matches {
+			t.Errorf("ParseCondition(%q).Matches(%v): expected %v, got %v", test.expr, test.values, test.matches, matches)
+		}
+	}
+}
+
+func TestParseConditionErrors(t *testing.T) {
+	tests := []string{
+		"os",
+		"os = 'linux'",
+		"platform == 'linux'",
+		"os == 'linux' &&",
+		"os == 'linux' || ",
+		"os == ",
+		"os == 'linux",
+		`os == 'linux"`,
+		"os == 'lin'ux'",
+		"branch == release 1",
+		"branch == 'release/['",
+	}
+
+	for _, expr := range tests {
+		if _, err := ParseCondition(expr); err == nil {
+			t.Errorf("ParseCondition(%q): expected an error", expr)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	PackageLocationPath = "path"
+	PackageLocationURL  = "url"
+)
+
+// PackageLocation is where a custom-built Godot or export templates package is installed from,
+// written as "path:/opt/godot-custom.zip" or "url:https://example.com/godot-custom.zip".
+type PackageLocation struct {
+	Kind     string
+	Location string
+}
+
+// ParsePackageLocation parses a package location such as "path:/opt/godot-custom.zip".
+func ParsePackageLocation(source string) (PackageLocation, error) {
+	kind, location, found := strings.Cut(source, ":")
+	if !found || location == "" {
+		return PackageLocation{}, fmt.Errorf("invalid package source %q, expected \"path:<file>\" or \"url:<url>\"", source)
+	}
+
+	switch kind {
+	case PackageLocationPath:
+	case PackageLocationURL:
+		if _, err := url.ParseRequestURI(location); err != nil {
+			return PackageLocation{}, fmt.Errorf("invalid package URL %q: %s", location, err)
+		}
+	default:
+		return PackageLocation{}, fmt.Errorf("unknown package source %q, expected %s or %s", kind, PackageLocationPath, PackageLocationURL)
+	}
+
+	return PackageLocation{Kind: kind, Location: location}, nil
+}
+
+// String returns the location as written in the config.
+func (l PackageLocation) String() string {
+	return l.Kind + ":" + l.Location
+}
+
+// FetchCustomPackage returns the local path of a custom package, downloading it into the cache
+// with the given headers when it is a URL. URLs must come with the package's SHA-512 checksum,
+// while local files are only verified when one is given.
+func (d *Downloader) FetchCustomPackage(targetOS TargetOS, engine GodotEngine, location PackageLocation, checksum string, headers http.Header) (string, error) {
+	if location.Kind == PackageLocationPath {
+		packagePath, err := filepath.Abs(location.Location)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(packagePath); err != nil {
+			return "", fmt.Errorf("failed to find custom package: %s", err)
+		}
+
+		if checksum != "" {
+			actual, err := FileSHA512(packagePath)
+			if err != nil {
+				return "", fmt.Errorf("failed to hash custom package: %s", err)
+			}
+			if err := verifyChecksum(filepath.Base(packagePath), checksum, actual); err != nil {
+				return "", err
+			}
+		}
+		return packagePath, nil
+	}
+
+	if checksum == "" {
+		return "", fmt.Errorf("a checksum is required to download %s", location.Location)
+	}
+
+	packageURL, err := url.Parse(location.Location)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse package URL: %s", err)
+	}
+
+	key := CacheKey{
+		Engine:   engine,
+		OS:       targetOS,
+		Checksum: checksum,
+		FileName: path.Base(packageURL.Path),
+	}
+
+	// Keep credentials out of the logs, like the headers of the download sources
+	for _, values := range headers {
+		for _, value := range values {
+			d.logger.Mask(value)
+		}
+	}
+
+	return d.cacheFile(key, func(partialPath string) error {
+		d.logger.Debugf("Download URL: %s", location.Location)
+		return d.downloadFile(location.Location, partialPath, checksum, headers)
+	})
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"archive/zip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFetchCustomPackageSendsHeaders(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if authorization != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write(testPackage)
+	}))
+	defer server.Close()
+
+	downloader := newTestDownloader(t)
+	location, err := ParsePackageLocation("url:" + server.URL + "/godot-custom.zip")
+	if err != nil {
+		t.Fatalf("failed to parse location: %s", err)
+	}
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer secret")
+
+	engine := GodotEngine{Version: "4.2.1", Release: "stable", Custom: true}
+	packagePath, err := downloader.FetchCustomPackage(TargetOSLinux, engine, location, testPackageChecksum(), headers)
+	if err != nil {
+		t.Fatalf("failed to fetch custom package: %s", err)
+	}
+	if authorization != "Bearer secret" {
+		t.Errorf("expected the Authorization header to be sent, got %q", authorization)
+	}
+	assertTestPackage(t, packagePath)
+}
+
+// writeTestZip writes a zip file holding the given files, mapped to their permissions.
+func writeTestZip(t *testing.T, files map[string]os.FileMode) string {
+	t.Helper()
+
+	zipPath := filepath.Join(t.TempDir(), "godot-custom.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("failed to create zip: %s", err)
+	}
+	defer f.Close()
+
+	writer := zip.NewWriter(f)
+	for name, mode := range files {
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+		header.SetMode(mode)
+		w, err := writer.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("failed to add %s: %s", name, err)
+		}
+		_, _ = w.Write([]byte(name))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to write zip: %s", err)
+	}
+	return zipPath
+}
+
+func TestUnzipCustomGodot(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executables are found by their permissions")
+	}
+
+	engine := GodotEngine{Version: "4.3", Release: "stable", Custom: true}
+	tests := []struct {
+		name     string
+		files    map[string]os.FileMode
+		binary   string
+		expected string
+	}{
+		{
+			name: "single executable",
+			files: map[string]os.FileMode{
+				"bin/godot.linuxbsd.editor.x86_64": 0755,
+				"bin/libgodot.so":                  0755,
+				"README.md":                        0644,
+			},
+			expected: "bin/godot.linuxbsd.editor.x86_64",
+		},
+		{
+			name: "binary key",
+			files: map[string]os.FileMode{
+				"bin/godot.linuxbsd.editor.x86_64":          0755,
+				"bin/godot.linuxbsd.editor.dev.x86_64.llvm": 0755,
+			},
+			binary:   "bin/godot.linuxbsd.editor.x86_64",
+			expected: "bin/godot.linuxbsd.editor.x86_64",
+		},
+		{
+			name: "official name",
+			files: map[string]os.FileMode{
+				"Godot_v4.3-stable_linux.x86_64": 0755,
+				"tools/strip":                    0755,
+			},
+			expected: "Godot_v4.3-stable_linux.x86_64",
+		},
+		{
+			name: "several executables",
+			files: map[string]os.FileMode{
+				"bin/godot.linuxbsd.editor.x86_64":          0755,
+				"bin/godot.linuxbsd.editor.dev.x86_64.llvm": 0755,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			downloader := newTestDownloader(t)
+			destDir := t.TempDir()
+
+			binPath, err := downloader.UnzipGodot(TargetOSLinux, TargetArchX86_64, engine, writeTestZip(t, test.files), destDir, test.binary)
+			if test.expected == "" {
+				if err == nil || !strings.Contains(err.Error(), "set `binary`") {
+					t.Fatalf("expected an error asking for `binary`, got %v %q", err, binPath)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if expected := filepath.Join(destDir, filepath.FromSlash(test.expected)); binPath != expected {
+				t.Errorf("expected %q, got %q", expected, binPath)
+			}
+		})
+	}
+}
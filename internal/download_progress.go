@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"io"
+	"time"
+
+	"github.com/yeslayla/godot-build-tools/logging"
+	"github.com/yeslayla/godot-build-tools/utils"
+)
+
+// downloadProgress wraps a download's body, periodically logging how much has been downloaded.
+type downloadProgress struct {
+	reader io.Reader
+	// stall is reset on every read, and cancels the download when it fires
+	stall        *time.Timer
+	stallTimeout time.Duration
+
+	name    string
+	written int64
+	total   int64
+	resumed int64
+
+	started  time.Time
+	logged   time.Time
+	interval time.Duration
+
+	logger logging.Logger
+}
+
+func (p *downloadProgress) Read(b []byte) (int, error) {
+	n, err := p.reader.Read(b)
+	p.written += int64(n)
+
+	if n > 0 {
+		p.stall.Reset(p.stallTimeout)
+	}
+	if time.Since(p.logged) >= p.interval {
+		p.log()
+	}
+
+	return n, err
+}
+
+// log logs the current progress, with the percentage when the size is known.
+func (p *downloadProgress) log() {
+	p.logged = time.Now()
+
+	var rate float64
+	if elapsed := time.Since(p.started).Seconds(); elapsed > 0 {
+		rate = float64(p.written-p.resumed) / elapsed
+	}
+
+	if p.total > 0 {
+		p.logger.Infof("Downloaded %s of %s (%d%%) at %s/s", utils.FormatBytes(p.written), utils.FormatBytes(p.total),
+			p.written*100/p.total, utils.FormatBytes(int64(rate)))
+		return
+	}
+	p.logger.Infof("Downloaded %s at %s/s", utils.FormatBytes(p.written), utils.FormatBytes(int64(rate)))
+}
@@ -1,27 +1,57 @@
 package internal
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
-	"net/url"
 	"os"
-	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/yeslayla/godot-build-tools/logging"
 	"github.com/yeslayla/godot-build-tools/utils"
 )
 
 type DownloaderOptions struct {
-	DownloadRepositoryURL string
-	BinDir                string
+	// Sources are tried in order when downloading, defaulting to DefaultDownloadSources
+	Sources    []DownloadSource
+	BinDir     string
+	EnginesDir string
+	CacheDir   string
+	// Timeout limits how long a download may take across all attempts, zero means no limit
+	Timeout time.Duration
+	// Retries is the number of times a failed download is retried, negative disables retries
+	Retries int
+
+	// HTTPClient is used for all requests, taking precedence over Proxy and CABundle
+	HTTPClient *http.Client
+	// Proxy is the URL of the proxy to connect through, defaulting to the proxy environment variables
+	Proxy string
+	// CABundle is the path of a PEM file with extra certificates to trust, for private mirrors
+	CABundle string
+
+	// Offline refuses network access, packages must be vendored or cached
+	Offline bool
+	// VendorDir is checked for packages before the cache, defaulting to DefaultVendorDir
+	VendorDir string
 }
 
 type Downloader struct {
-	downloadRepositoryURL string
-	bin                   string
+	sources []DownloadSource
+	client  *http.Client
+	engines *EngineManager
+	cache   *Cache
+	timeout time.Duration
+	retries int
+	// retryDelay is the delay before the first retry, doubling with every retry after it
+	retryDelay time.Duration
+	// stallTimeout cancels a download attempt that receives no data for this long
+	stallTimeout time.Duration
+
+	offline   bool
+	vendorDir string
 
 	logger logging.Logger
 }
@@ -41,141 +71,439 @@ func DefaultBinDir(targetOS TargetOS) string {
 }
 
 func NewDownloader(targetOS TargetOS, logger logging.Logger, options *DownloaderOptions) *Downloader {
-	var url string = options.DownloadRepositoryURL
-	if url == "" {
-		url = "https://downloads.tuxfamily.org/godotengine/"
+	var sources []DownloadSource = options.Sources
+	if len(sources) == 0 {
+		sources = DefaultDownloadSources()
+	}
+	// Keep credentials out of the logs, download URLs are logged in debug mode
+	for _, source := range sources {
+		for _, values := range source.Headers() {
+			for _, value := range values {
+				logger.Mask(value)
+			}
+		}
+	}
+	if password := proxyPassword(options.Proxy); password != "" {
+		logger.Mask(password)
+	}
+
+	var client *http.Client = options.HTTPClient
+	if client == nil {
+		var err error
+		client, err = newHTTPClient(options.Proxy, options.CABundle)
+		if err != nil {
+			logger.Errorf("Failed to configure HTTP client, using the defaults: %s", err)
+			client = http.DefaultClient
+		}
+	}
+
+	var vendorDir string = options.VendorDir
+	if vendorDir == "" {
+		vendorDir = DefaultVendorDir
 	}
-	var binDir string = options.BinDir
-	if binDir == "" {
-		DefaultBinDir(targetOS)
+
+	var retries int = options.Retries
+	if retries == 0 {
+		retries = defaultDownloadRetries
+	} else if retries < 0 {
+		retries = 0
 	}
 
 	return &Downloader{
-		downloadRepositoryURL: url,
-		bin:                   binDir,
-		logger:                logger,
+		sources: sources,
+		client:  client,
+		timeout: options.Timeout,
+		retries: retries,
+
+		retryDelay:   downloadRetryBaseDelay,
+		stallTimeout: downloadStallTimeout,
+
+		offline:   options.Offline,
+		vendorDir: vendorDir,
+		engines: NewEngineManager(targetOS, logger, &EngineManagerOptions{
+			EnginesDir: options.EnginesDir,
+			BinDir:     options.BinDir,
+		}),
+		cache:  NewCache(options.CacheDir, logger),
+		logger: logger,
 	}
 }
 
-// getRemoteFileName returns the name of the Godot package file for the given target OS, version and release.
-func getRemoteFileName(targetOS TargetOS, version string, release string) string {
-	switch targetOS {
-	case TargetOSLinux:
-		if version[0] == '3' {
-			return fmt.Sprintf("Godot_v%s-%s_x11.64.zip", version, release)
+// getRemoteFileName returns the name of the Godot package file for the given target and engine.
+func getRemoteFileName(targetOS TargetOS, arch TargetArch, engine GodotEngine) (string, error) {
+	platform, err := godotPlatformName(targetOS, arch, engine)
+	if err != nil {
+		return "", err
+	}
+
+	var prefix string = fmt.Sprintf("Godot_v%s-%s", engine.Version, engine.Release)
+
+	if engine.Mono {
+		// Mono packages are directories, named with underscores instead of dots on Linux
+		if targetOS == TargetOSLinux {
+			platform = strings.ReplaceAll(platform, ".", "_")
 		}
-		return fmt.Sprintf("Godot_v%s-%s_linux.x86_64.zip", version, release)
-	case TargetOSWindows:
-		return fmt.Sprintf("Godot_v%s-%s_win64.exe.zip", version, release)
-	case TargetOSMacOS:
-		return fmt.Sprintf("Godot_v%s-%s_macos.universal.zip", version, release)
+		return prefix + "_mono_" + platform + ".zip", nil
 	}
 
-	return ""
+	if targetOS == TargetOSWindows {
+		return prefix + "_" + platform + ".exe.zip", nil
+	}
+	return prefix + "_" + platform + ".zip", nil
 }
 
-// DownloadGodot downloads the Godot package for the given target OS, version, and release.
-func (d *Downloader) DownloadGodot(targetOS TargetOS, version string, release string) (string, error) {
+// releaseURL returns the URL of a file of the given engine release from the first download source.
+func (d *Downloader) releaseURL(engine GodotEngine, fileName string) (string, error) {
+	return d.sources[0].FileURL(engine, fileName)
+}
 
-	var fileName string = getRemoteFileName(targetOS, version, release)
+// fetchChecksums downloads and parses the release's SHA512-SUMS.txt from the first source that has it.
+func (d *Downloader) fetchChecksums(engine GodotEngine) (map[string]string, error) {
+	if content, err := os.ReadFile(d.vendorPath(engine, checksumsFileName)); err == nil {
+		return ParseChecksums(string(content)), nil
+	}
 
-	// Create output file
-	tempDir, _ := os.MkdirTemp("", "godot-build-tools")
-	outFile := filepath.Join(tempDir, fileName)
-	out, err := os.Create(outFile)
-	if err != nil {
-		return "", fmt.Errorf("failed to create output file: %s", err)
+	var lastErr error
+	for _, source := range d.sources {
+		sumsURL, err := source.FileURL(engine, checksumsFileName)
+		if err != nil {
+			return nil, err
+		}
+		d.logger.Debugf("Checksums URL: %s", sumsURL)
+
+		content, err := d.fetch(sumsURL, source.Headers())
+		if err != nil {
+			d.logger.Debugf("Failed to get %s from %s: %s", checksumsFileName, source.Name(), err)
+			lastErr = err
+			continue
+		}
+
+		return ParseChecksums(string(content)), nil
 	}
-	defer out.Close()
 
-	// Calculate download URL
-	downloadURL, err := url.Parse(d.downloadRepositoryURL)
+	return nil, lastErr
+}
+
+// fetchChecksum looks up the expected hash of a release file in the release's SHA512-SUMS.txt.
+func (d *Downloader) fetchChecksum(engine GodotEngine, fileName string) (string, error) {
+	checksums, err := d.fetchChecksums(engine)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse download repository URL: %s", err)
+		return "", err
+	}
+
+	checksum, ok := checksums[fileName]
+	if !ok {
+		return "", fmt.Errorf("%s has no entry for %s", checksumsFileName, fileName)
 	}
 
-	downloadURL.Path = path.Join(downloadURL.Path, version)
-	if release != "stable" {
-		downloadURL.Path = path.Join(downloadURL.Path, release)
+	return checksum, nil
+}
+
+// fetch downloads a small file into memory, sending the given extra headers.
+func (d *Downloader) fetch(fileURL string, headers http.Header) ([]byte, error) {
+	if d.offline {
+		return nil, fmt.Errorf("refusing to download %s in offline mode", fileURL)
 	}
 
-	downloadURL.Path = path.Join(downloadURL.Path, fileName)
-	d.logger.Debugf("Download URL: %s", downloadURL.String())
+	req, err := http.NewRequest(http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %s", err)
+	}
+	for name, values := range headers {
+		req.Header[name] = values
+	}
 
-	// Download Godot package
-	resp, err := http.Get(downloadURL.String())
+	resp, err := d.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to download Godot: %s", err)
+		return nil, &NetworkError{fmt.Errorf("failed to download %s: %s", fileURL, err)}
 	}
 	defer resp.Body.Close()
 
-	// Write Godot package to output file
-	_, err = io.Copy(out, resp.Body)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, &NetworkError{fmt.Errorf("failed to download %s: %s", fileURL, resp.Status)}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("failed to download %s: %s", fileURL, resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to write Godot package to output file: %s", err)
+		return nil, &NetworkError{fmt.Errorf("failed to read %s: %s", fileURL, err)}
 	}
+	return content, nil
+}
 
-	return outFile, nil
+// DownloadGodot downloads the Godot package for the given target and engine,
+// returning its path in the download cache.
+// The package is verified against the given SHA-512 checksum, or against the release's
+// SHA512-SUMS.txt when no checksum is given.
+func (d *Downloader) DownloadGodot(targetOS TargetOS, arch TargetArch, engine GodotEngine, checksum string) (string, error) {
+	fileName, err := getRemoteFileName(targetOS, arch, engine)
+	if err != nil {
+		return "", err
+	}
+	return d.downloadReleaseFile(targetOS, engine, fileName, checksum)
 }
 
-// isTargetOSBin returns true if the given file name is a binary for the given target OS.
-func isTargetOSBin(targetOS TargetOS, fileName string) bool {
-	switch targetOS {
-	case TargetOSLinux:
-		if path.Ext(fileName) == ".x86_64" || path.Ext(fileName) == ".64" {
-			return true
+// downloadReleaseFile downloads a file of a Godot release into the cache, unless it is already cached.
+func (d *Downloader) downloadReleaseFile(targetOS TargetOS, engine GodotEngine, fileName string, checksum string) (string, error) {
+	if checksum == "" {
+		var err error
+		checksum, err = d.fetchChecksum(engine, fileName)
+		if err != nil {
+			return "", fmt.Errorf("failed to get checksum, set `checksum` in [godot] to verify the package manually: %w", err)
 		}
-	case TargetOSWindows:
-		if path.Ext(fileName) == ".exe" {
-			return true
+	}
+	d.logger.Debugf("Expected SHA-512: %s", checksum)
+
+	key := CacheKey{
+		Engine:   engine,
+		OS:       targetOS,
+		Checksum: checksum,
+		FileName: fileName,
+	}
+
+	return d.cacheFile(key, func(partialPath string) error {
+		// Fall through the sources in order until one of them serves the file
+		var errs []error
+		for _, source := range d.sources {
+			downloadURL, err := source.FileURL(engine, fileName)
+			if err != nil {
+				return err
+			}
+			d.logger.Debugf("Download URL: %s", downloadURL)
+
+			err = d.downloadFile(downloadURL, partialPath, checksum, source.Headers())
+			if err == nil {
+				d.logger.Infof("Downloaded %s from %s", fileName, source.Name())
+				return nil
+			}
+			d.logger.Warnf("Failed to download %s from %s: %s", fileName, source.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
 		}
-	case TargetOSMacOS:
-		if path.Ext(fileName) == ".universal" {
-			return true
+		return fmt.Errorf("failed to download %s from any source:\n%w", fileName, errors.Join(errs...))
+	})
+}
+
+// cacheFile returns the path of the vendored or cached file for the given key. On a cache miss,
+// download is called to fetch the file into the given partial path, which is then moved into the cache.
+func (d *Downloader) cacheFile(key CacheKey, download func(partialPath string) error) (string, error) {
+	var fileName string = key.FileName
+	if vendored, ok := d.lookupVendor(key); ok {
+		d.logger.Infof("Using vendored %s", fileName)
+		return vendored, nil
+	}
+	if cached, ok := d.cache.Lookup(key); ok {
+		d.logger.Infof("Using cached %s", fileName)
+		return cached, nil
+	}
+	if d.offline {
+		return "", fmt.Errorf("%s is not in %s or the cache, and downloads are disabled in offline mode", fileName, d.vendorDir)
+	}
+
+	unlock, err := d.cache.Lock(key)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	// Another job may have downloaded the file while we waited for the lock
+	if cached, ok := d.cache.Lookup(key); ok {
+		d.logger.Infof("Using cached %s", fileName)
+		return cached, nil
+	}
+
+	cachePath := d.cache.Path(key)
+	partialPath := cachePath + cachePartialSuffix
+	if err := download(partialPath); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(partialPath, cachePath); err != nil {
+		_ = os.Remove(partialPath)
+		return "", fmt.Errorf("failed to move %s into cache: %s", fileName, err)
+	}
+
+	return cachePath, nil
+}
+
+// GodotPackageURLs returns the name of the Godot package for the given target and engine,
+// along with its URL on each download source in the order they are tried.
+func (d *Downloader) GodotPackageURLs(targetOS TargetOS, arch TargetArch, engine GodotEngine) (string, []string, error) {
+	fileName, err := getRemoteFileName(targetOS, arch, engine)
+	if err != nil {
+		return "", nil, err
+	}
+
+	urls, err := d.releaseFileURLs(engine, fileName)
+	return fileName, urls, err
+}
+
+// releaseFileURLs returns the URL of a release file on each download source.
+func (d *Downloader) releaseFileURLs(engine GodotEngine, fileName string) ([]string, error) {
+	var urls []string = make([]string, 0, len(d.sources))
+	for _, source := range d.sources {
+		fileURL, err := source.FileURL(engine, fileName)
+		if err != nil {
+			return nil, err
 		}
+		urls = append(urls, fileURL)
 	}
+	return urls, nil
+}
 
-	return false
+// GodotBinaryName returns the path of the Godot binary within an installed engine's directory.
+func GodotBinaryName(targetOS TargetOS, arch TargetArch, engine GodotEngine) (string, error) {
+	if targetOS == TargetOSMacOS {
+		if engine.Mono {
+			return "Godot_mono.app/Contents/MacOS/Godot", nil
+		}
+		return "Godot.app/Contents/MacOS/Godot", nil
+	}
+
+	platform, err := godotPlatformName(targetOS, arch, engine)
+	if err != nil {
+		return "", err
+	}
+
+	var name string = fmt.Sprintf("Godot_v%s-%s", engine.Version, engine.Release)
+	if engine.Mono {
+		name += "_mono"
+	}
+	name += "_" + platform
+	if targetOS == TargetOSWindows {
+		name += ".exe"
+	}
+	return name, nil
 }
 
-func (d *Downloader) UnzipGodot(targetOS TargetOS, godotPackage string) (string, error) {
-	files, err := utils.Unzip(godotPackage)
+// isTargetOSBin returns true if the given file name is the Godot binary for the given target.
+func isTargetOSBin(targetOS TargetOS, arch TargetArch, engine GodotEngine, fileName string) bool {
+	if targetOS == TargetOSMacOS {
+		return strings.Contains(filepath.ToSlash(fileName), ".app/Contents/MacOS/")
+	}
+
+	platform, err := godotPlatformName(targetOS, arch, engine)
+	if err != nil {
+		return false
+	}
+
+	var suffix string = "_" + platform
+	if engine.Mono {
+		suffix = "_mono" + suffix
+	}
+	if targetOS == TargetOSWindows {
+		suffix += ".exe"
+	}
+
+	return strings.HasSuffix(fileName, suffix)
+}
+
+// UnzipGodot unzips a Godot package into the destination directory, returning the path of the
+// Godot binary. The binary is the given path within the package, or is found by its name when
+// the path is empty.
+func (d *Downloader) UnzipGodot(targetOS TargetOS, arch TargetArch, engine GodotEngine, godotPackage string, destDir string, binary string) (string, error) {
+	files, err := utils.UnzipTo(godotPackage, destDir)
 	if err != nil {
 		return "", fmt.Errorf("failed to unzip Godot package: %s", err)
 	}
 
+	if binary != "" {
+		binPath := filepath.Join(destDir, filepath.FromSlash(binary))
+		if info, err := os.Stat(binPath); err != nil || info.IsDir() {
+			return "", fmt.Errorf("failed to find %s in Godot package", binary)
+		}
+		return binPath, nil
+	}
+
 	// Look for godot binary
 	for _, file := range files {
-		if isTargetOSBin(targetOS, file) {
+		if isTargetOSBin(targetOS, arch, engine, file) {
 			return file, nil
 		}
 	}
 
+	// Custom builds keep the names SCons gives them, such as godot.linuxbsd.editor.x86_64
+	if engine.Custom {
+		if binPath, ok := singleExecutable(targetOS, files); ok {
+			return binPath, nil
+		}
+		return "", fmt.Errorf("failed to find godot binary in custom Godot package, set `binary` in [godot] to its path in the package")
+	}
+
 	return "", fmt.Errorf("failed to find godot binary in Godot package")
 }
 
-func (d *Downloader) InstallGodot(godotPackage string, targetOS TargetOS, version string, release string) (string, error) {
+// singleExecutable returns the only executable among the given files, if there is exactly one.
+// Console wrappers and shared libraries aren't counted.
+func singleExecutable(targetOS TargetOS, files []string) (string, bool) {
+	var found []string
+	for _, file := range files {
+		name := strings.ToLower(filepath.Base(file))
+		if strings.Contains(name, "console") {
+			continue
+		}
+
+		if targetOS == TargetOSWindows {
+			if strings.HasSuffix(name, ".exe") {
+				found = append(found, file)
+			}
+			continue
+		}
+
+		if strings.HasSuffix(name, ".so") || strings.Contains(name, ".so.") || strings.HasSuffix(name, ".dylib") {
+			continue
+		}
+		if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0 {
+			found = append(found, file)
+		}
+	}
+
+	if len(found) != 1 {
+		return "", false
+	}
+	return found[0], true
+}
+
+// InstallGodot installs the engine from a Godot package into its own versioned directory,
+// returning the path of the Godot binary. Mono builds need the GodotSharp directory next to the
+// binary and macOS builds are app bundles, so the whole package is installed rather than just the binary.
+// The binary is the given path within the package, or is found by its name when the path is empty.
+func (d *Downloader) InstallGodot(godotPackage string, targetOS TargetOS, arch TargetArch, engine GodotEngine, binary string) (string, error) {
+	checksum, err := FileSHA512(godotPackage)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash Godot package: %s", err)
+	}
 
-	// Unzip package
-	godotUnzipBinPath, err := d.UnzipGodot(targetOS, godotPackage)
+	stagingDir, err := d.engines.StagingDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %s", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	godotUnzipBinPath, err := d.UnzipGodot(targetOS, arch, engine, godotPackage, stagingDir, binary)
 	if err != nil {
 		return "", fmt.Errorf("failed to unzip Godot package: %s", err)
 	}
 
-	godotBin := path.Base(godotUnzipBinPath)
-	godotBinPath := filepath.Join(d.bin, godotBin)
+	// Mono packages keep the binary and GodotSharp in a directory of their own
+	installRoot := stagingDir
+	if engine.Mono && targetOS != TargetOSMacOS {
+		installRoot = filepath.Dir(godotUnzipBinPath)
+		if _, err := os.Stat(filepath.Join(installRoot, "GodotSharp")); err != nil {
+			return "", fmt.Errorf("failed to find GodotSharp next to the Godot binary: %s", err)
+		}
+	}
 
-	// Copy Godot binary to bin directory
-	data, err := ioutil.ReadFile(godotUnzipBinPath)
+	relBinPath, err := filepath.Rel(installRoot, godotUnzipBinPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read Godot binary: %s", err)
+		return "", err
 	}
-	err = ioutil.WriteFile(godotBinPath, data, 0755)
+
+	installed, err := d.engines.Install(engine, installRoot, relBinPath, checksum)
 	if err != nil {
-		return "", fmt.Errorf("failed to write Godot binary: %s", err)
+		return "", err
 	}
 
-	_ = os.Remove(godotUnzipBinPath)
-
-	return godotBinPath, nil
+	return installed.Binary, nil
 }
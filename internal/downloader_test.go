@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestDownloader creates a downloader using the given sources, with its directories in a temporary directory.
+func newTestDownloader(t *testing.T, sources ...DownloadSource) *Downloader {
+	t.Helper()
+	t.Setenv(CacheDirEnv, "")
+
+	dir := t.TempDir()
+	return NewDownloader(TargetOSLinux, &recordingLogger{}, &DownloaderOptions{
+		Sources:    sources,
+		BinDir:     filepath.Join(dir, "bin"),
+		EnginesDir: filepath.Join(dir, "engines"),
+		CacheDir:   filepath.Join(dir, "cache"),
+		VendorDir:  filepath.Join(dir, "vendor"),
+		Retries:    -1,
+	})
+}
+
+// newTestSource creates a custom download source serving files from the given server.
+func newTestSource(t *testing.T, name string, server *httptest.Server) DownloadSource {
+	t.Helper()
+
+	source, err := NewDownloadSource(SourceTypeCustom, &DownloadSourceOptions{Name: name, URL: server.URL + "/{file}"})
+	if err != nil {
+		t.Fatalf("failed to create source: %s", err)
+	}
+	return source
+}
+
+func TestDownloadGodotReportsEverySource(t *testing.T) {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	forbidden := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer forbidden.Close()
+
+	downloader := newTestDownloader(t, newTestSource(t, "first", notFound), newTestSource(t, "second", forbidden))
+	engine := GodotEngine{Version: "4.2.1", Release: "stable"}
+
+	_, err := downloader.DownloadGodot(TargetOSLinux, TargetArchX86_64, engine, strings.Repeat("0", 128))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	for _, expected := range []string{"from any source", "first: ", "404 Not Found", "second: ", "403 Forbidden"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %q, got %q", expected, err)
+		}
+	}
+}
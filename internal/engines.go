@@ -0,0 +1,358 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+const engineMetadataFile = "engine.toml"
+
+// enginesLockFile is locked in the engines directory while engines are installed, removed or
+// switched between, so concurrent jobs don't interleave changes.
+const enginesLockFile = ".lock"
+
+// userDataDir returns the directory applications store user data in on the given target OS.
+func userDataDir(targetOS TargetOS) string {
+	home, _ := os.UserHomeDir()
+	switch targetOS {
+	case TargetOSLinux:
+		if dataDir := os.Getenv("XDG_DATA_HOME"); dataDir != "" {
+			return dataDir
+		}
+		return filepath.Join(home, ".local", "share")
+	case TargetOSWindows:
+		return os.Getenv("APPDATA")
+	case TargetOSMacOS:
+		return filepath.Join(home, "Library", "Application Support")
+	}
+	return ""
+}
+
+// DefaultEnginesDir returns the directory Godot engines are installed to on the given target OS.
+func DefaultEnginesDir(targetOS TargetOS) string {
+	return filepath.Join(userDataDir(targetOS), "gbt", "engines")
+}
+
+// ParseEngineName parses an engine name such as "4.2.1", "4.2.1-rc1", "4.2.1-stable-mono" or
+// "4.2.1-stable-custom". The release defaults to stable.
+func ParseEngineName(name string) (GodotEngine, error) {
+	engine := GodotEngine{Release: "stable"}
+
+	name, engine.Custom = strings.CutSuffix(name, "-custom")
+	name, engine.Mono = strings.CutSuffix(name, "-mono")
+	version, release, found := strings.Cut(name, "-")
+	if found {
+		engine.Release = release
+	}
+	if _, err := ParseGodotVersion(version); err != nil {
+		return engine, err
+	}
+	engine.Version = version
+
+	return engine, nil
+}
+
+// InstalledEngine describes an engine installed by the engine manager.
+type InstalledEngine struct {
+	Engine GodotEngine
+	Dir    string
+	Binary string
+	SHA512 string
+	Active bool
+}
+
+// engineMetadata is stored alongside each installed engine.
+type engineMetadata struct {
+	Version string `toml:"version"`
+	Release string `toml:"release"`
+	Mono    bool   `toml:"mono"`
+	Custom  bool   `toml:"custom"`
+	Binary  string `toml:"binary"`
+	SHA512  string `toml:"sha512"`
+}
+
+// EngineManagerOptions holds options for creating a new engine manager.
+type EngineManagerOptions struct {
+	EnginesDir string
+	BinDir     string
+}
+
+// EngineManager installs Godot engines side by side in versioned directories, such as
+// engines/4.2.1-stable-mono, and switches a `godot` shim in the bin directory between them.
+type EngineManager struct {
+	targetOS   TargetOS
+	enginesDir string
+	binDir     string
+
+	logger logging.Logger
+}
+
+// NewEngineManager creates a new engine manager.
+func NewEngineManager(targetOS TargetOS, logger logging.Logger, options *EngineManagerOptions) *EngineManager {
+	var enginesDir string = options.EnginesDir
+	if enginesDir == "" {
+		enginesDir = DefaultEnginesDir(targetOS)
+	}
+	var binDir string = options.BinDir
+	if binDir == "" {
+		binDir = DefaultBinDir(targetOS)
+	}
+
+	return &EngineManager{
+		targetOS:   targetOS,
+		enginesDir: enginesDir,
+		binDir:     binDir,
+		logger:     logger,
+	}
+}
+
+// Dir returns the directory engines are installed to.
+func (m *EngineManager) Dir() string {
+	return m.enginesDir
+}
+
+// EngineDir returns the directory the given engine is installed to.
+func (m *EngineManager) EngineDir(engine GodotEngine) string {
+	return filepath.Join(m.enginesDir, engine.String())
+}
+
+// ShimPath returns the path of the `godot` shim that points at the active engine.
+func (m *EngineManager) ShimPath() string {
+	if m.targetOS == TargetOSWindows {
+		return filepath.Join(m.binDir, "godot.cmd")
+	}
+	return filepath.Join(m.binDir, "godot")
+}
+
+// Install moves an unpacked engine directory into place, replacing any previous install of the engine.
+// The binary is given relative to the unpacked directory.
+func (m *EngineManager) Install(engine GodotEngine, unpackedDir string, binary string, checksum string) (*InstalledEngine, error) {
+	metadata := engineMetadata{
+		Version: engine.Version,
+		Release: engine.Release,
+		Mono:    engine.Mono,
+		Custom:  engine.Custom,
+		Binary:  filepath.ToSlash(binary),
+		SHA512:  checksum,
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(metadata); err != nil {
+		return nil, fmt.Errorf("failed to encode engine metadata: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(unpackedDir, engineMetadataFile), buf.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write engine metadata: %s", err)
+	}
+
+	lock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	// The previous install is moved aside before it is deleted, so jobs reusing it never
+	// find it half deleted
+	engineDir := m.EngineDir(engine)
+	previousDir, err := m.moveAside(engine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove previous install: %s", err)
+	}
+	if err := os.Rename(unpackedDir, engineDir); err != nil {
+		return nil, fmt.Errorf("failed to install Godot: %s", err)
+	}
+	if err := os.RemoveAll(previousDir); err != nil {
+		m.logger.Warnf("Failed to remove previous install of Godot %s: %s", engine, err)
+	}
+
+	return m.Get(engine)
+}
+
+// lock takes the lock on the engines directory, waiting for other jobs changing the engines.
+func (m *EngineManager) lock() (*fileLock, error) {
+	if err := os.MkdirAll(m.enginesDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create engines directory: %s", err)
+	}
+	return waitFileLock(filepath.Join(m.enginesDir, enginesLockFile), cacheLockTimeout, func() {
+		m.logger.Infof("Waiting for another job to finish changing the engines in %s", m.enginesDir)
+	})
+}
+
+// moveAside moves an installed engine out of the way with a rename, returning the directory it
+// was moved to for deleting. It must be called with the lock held.
+func (m *EngineManager) moveAside(engine GodotEngine) (string, error) {
+	asideDir := filepath.Join(m.enginesDir, ".gbt-previous-"+engine.String())
+	if err := os.RemoveAll(asideDir); err != nil {
+		return "", err
+	}
+	if err := os.Rename(m.EngineDir(engine), asideDir); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	return asideDir, nil
+}
+
+// StagingDir creates a directory to unpack an engine into before it is installed.
+// It is created next to the installed engines so it can be moved into place with a rename.
+func (m *EngineManager) StagingDir() (string, error) {
+	if err := os.MkdirAll(m.enginesDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create engines directory: %s", err)
+	}
+	return os.MkdirTemp(m.enginesDir, ".gbt-")
+}
+
+// Get returns the installed engine, or an error if it is not installed.
+func (m *EngineManager) Get(engine GodotEngine) (*InstalledEngine, error) {
+	engineDir := m.EngineDir(engine)
+
+	var metadata engineMetadata
+	if _, err := toml.DecodeFile(filepath.Join(engineDir, engineMetadataFile), &metadata); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("Godot %s is not installed", engine)
+		}
+		return nil, fmt.Errorf("failed to read engine metadata: %s", err)
+	}
+
+	installed := &InstalledEngine{
+		Engine: engine,
+		Dir:    engineDir,
+		Binary: filepath.Join(engineDir, filepath.FromSlash(metadata.Binary)),
+		SHA512: metadata.SHA512,
+	}
+	if _, err := os.Stat(installed.Binary); err != nil {
+		return nil, fmt.Errorf("Godot %s is missing its binary: %s", engine, err)
+	}
+
+	installed.Active = m.activeBinary() == installed.Binary
+	return installed, nil
+}
+
+// List returns every installed engine, newest first.
+func (m *EngineManager) List() ([]*InstalledEngine, error) {
+	dirs, err := os.ReadDir(m.enginesDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*InstalledEngine{}, nil
+		}
+		return nil, err
+	}
+
+	var engines []*InstalledEngine = make([]*InstalledEngine, 0)
+	for _, dir := range dirs {
+		if !dir.IsDir() || strings.HasPrefix(dir.Name(), ".") {
+			continue
+		}
+
+		engine, err := ParseEngineName(dir.Name())
+		if err != nil {
+			continue
+		}
+		installed, err := m.Get(engine)
+		if err != nil {
+			m.logger.Warnf("Skipping %s: %s", dir.Name(), err)
+			continue
+		}
+		engines = append(engines, installed)
+	}
+
+	sort.SliceStable(engines, func(i, j int) bool {
+		a, _ := ParseGodotVersion(engines[i].Engine.Version)
+		b, _ := ParseGodotVersion(engines[j].Engine.Version)
+		if cmp := a.Compare(b); cmp != 0 {
+			return cmp > 0
+		}
+		return engines[i].Engine.String() < engines[j].Engine.String()
+	})
+	return engines, nil
+}
+
+// Remove uninstalls an engine, along with the shim if it points at the engine.
+func (m *EngineManager) Remove(engine GodotEngine) error {
+	lock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	installed, err := m.Get(engine)
+	if err != nil {
+		return err
+	}
+
+	if installed.Active {
+		if err := os.Remove(m.ShimPath()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove shim: %s", err)
+		}
+	}
+
+	asideDir, err := m.moveAside(engine)
+	if err != nil {
+		return fmt.Errorf("failed to remove Godot %s: %s", engine, err)
+	}
+	return os.RemoveAll(asideDir)
+}
+
+// Use points the `godot` shim at an installed engine, returning the shim's path.
+func (m *EngineManager) Use(engine GodotEngine) (string, error) {
+	lock, err := m.lock()
+	if err != nil {
+		return "", err
+	}
+	defer lock.release()
+
+	installed, err := m.Get(engine)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(m.binDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create bin directory: %s", err)
+	}
+
+	shimPath := m.ShimPath()
+	if err := os.Remove(shimPath); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to remove previous shim: %s", err)
+	}
+
+	// Symlinks need extra privileges on Windows, so a batch file forwards to the engine there
+	if m.targetOS == TargetOSWindows {
+		shim := fmt.Sprintf("@\"%s\" %%*\r\n", installed.Binary)
+		if err := os.WriteFile(shimPath, []byte(shim), 0755); err != nil {
+			return "", fmt.Errorf("failed to write shim: %s", err)
+		}
+		return shimPath, nil
+	}
+
+	if err := os.Symlink(installed.Binary, shimPath); err != nil {
+		return "", fmt.Errorf("failed to create shim: %s", err)
+	}
+	return shimPath, nil
+}
+
+// activeBinary returns the binary the shim points at, or an empty string if there is no shim.
+func (m *EngineManager) activeBinary() string {
+	shimPath := m.ShimPath()
+
+	if m.targetOS == TargetOSWindows {
+		content, err := os.ReadFile(shimPath)
+		if err != nil {
+			return ""
+		}
+		parts := strings.Split(string(content), "\"")
+		if len(parts) < 3 {
+			return ""
+		}
+		return parts[1]
+	}
+
+	target, err := os.Readlink(shimPath)
+	if err != nil {
+		return ""
+	}
+	return target
+}
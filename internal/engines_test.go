@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseEngineName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected GodotEngine
+	}{
+		{"4.2.1", GodotEngine{Version: "4.2.1", Release: "stable"}},
+		{"4.2.1-rc1", GodotEngine{Version: "4.2.1", Release: "rc1"}},
+		{"4.2.1-stable-mono", GodotEngine{Version: "4.2.1", Release: "stable", Mono: true}},
+		{"4.2.1-stable-custom", GodotEngine{Version: "4.2.1", Release: "stable", Custom: true}},
+		{"4.2.1-stable-mono-custom", GodotEngine{Version: "4.2.1", Release: "stable", Mono: true, Custom: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine, err := ParseEngineName(test.name)
+			if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if engine != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, engine)
+			}
+			if test.name != "4.2.1" && engine.String() != test.name {
+				t.Errorf("expected the name to round trip, got %q", engine.String())
+			}
+		})
+	}
+}
+
+// stageTestEngine creates an unpacked engine with a fake binary, ready to be installed.
+func stageTestEngine(t *testing.T, manager *EngineManager, content string) string {
+	t.Helper()
+
+	stagingDir, err := manager.StagingDir()
+	if err != nil {
+		t.Fatalf("failed to create staging directory: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(stagingDir, "godot"), []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write binary: %s", err)
+	}
+	return stagingDir
+}
+
+func TestEngineManagerInstallWaitsForLock(t *testing.T) {
+	dir := t.TempDir()
+	manager := NewEngineManager(TargetOSLinux, &recordingLogger{}, &EngineManagerOptions{
+		EnginesDir: filepath.Join(dir, "engines"),
+		BinDir:     filepath.Join(dir, "bin"),
+	})
+	engine := GodotEngine{Version: "4.2.1", Release: "stable"}
+
+	if _, err := manager.Install(engine, stageTestEngine(t, manager, "old"), "godot", "old"); err != nil {
+		t.Fatalf("failed to install: %s", err)
+	}
+
+	// Another job holds the lock while the engine is reinstalled
+	lock, err := tryFileLock(filepath.Join(manager.Dir(), enginesLockFile))
+	if err != nil || lock == nil {
+		t.Fatalf("failed to take the engines lock: %v", err)
+	}
+
+	done := make(chan error)
+	stagingDir := stageTestEngine(t, manager, "new")
+	go func() {
+		_, err := manager.Install(engine, stagingDir, "godot", "new")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("expected the install to wait for the lock, got %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+	if installed, err := manager.Get(engine); err != nil || installed.SHA512 != "old" {
+		t.Fatalf("expected the previous install to be intact while locked, got %+v %v", installed, err)
+	}
+
+	lock.release()
+	if err := <-done; err != nil {
+		t.Fatalf("failed to reinstall: %s", err)
+	}
+
+	installed, err := manager.Get(engine)
+	if err != nil || installed.SHA512 != "new" {
+		t.Fatalf("expected the new install, got %+v %v", installed, err)
+	}
+	content, _ := os.ReadFile(installed.Binary)
+	if string(content) != "new" {
+		t.Errorf("expected the new binary, got %q", content)
+	}
+
+	// Only the engine is left behind, not the previous install or the lock
+	entries, _ := os.ReadDir(manager.Dir())
+	if len(entries) != 1 || entries[0].Name() != engine.String() {
+		t.Errorf("expected only %s in the engines directory, got %v", engine, entries)
+	}
+
+	if err := manager.Remove(engine); err != nil {
+		t.Fatalf("failed to remove: %s", err)
+	}
+	if _, err := manager.Get(engine); err == nil {
+		t.Errorf("expected the engine to be removed")
+	}
+}
@@ -0,0 +1,10 @@
+package internal
+
+// Exit codes of gbt, so CI can tell apart why a build failed.
+const (
+	ExitCodeFailure    = 1
+	ExitCodeConfig     = 2
+	ExitCodeNetwork    = 3
+	ExitCodeGodot      = 4
+	ExitCodeValidation = 5
+)
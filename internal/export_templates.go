@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yeslayla/godot-build-tools/utils"
+)
+
+// getExportTemplatesFileName returns the name of the export templates package for the given engine.
+func getExportTemplatesFileName(engine GodotEngine) string {
+	if engine.Mono {
+		return fmt.Sprintf("Godot_v%s-%s_mono_export_templates.tpz", engine.Version, engine.Release)
+	}
+	return fmt.Sprintf("Godot_v%s-%s_export_templates.tpz", engine.Version, engine.Release)
+}
+
+// ExportTemplatesVersion returns the name of the directory Godot looks for export templates in,
+// such as "4.2.1.stable" or "4.2.1.stable.mono".
+func ExportTemplatesVersion(engine GodotEngine) string {
+	if engine.Mono {
+		return fmt.Sprintf("%s.%s.mono", engine.Version, engine.Release)
+	}
+	return fmt.Sprintf("%s.%s", engine.Version, engine.Release)
+}
+
+// DefaultExportTemplatesDir returns the directory Godot loads export templates from on the given target OS.
+// Godot 3 uses a "templates" directory, while Godot 4 uses "export_templates".
+func DefaultExportTemplatesDir(targetOS TargetOS, version string) string {
+	var templatesDir string = "export_templates"
+	if godotMajorVersion(version) == 3 {
+		templatesDir = "templates"
+	}
+
+	switch targetOS {
+	case TargetOSLinux:
+		return filepath.Join(userDataDir(targetOS), "godot", templatesDir)
+	case TargetOSWindows, TargetOSMacOS:
+		return filepath.Join(userDataDir(targetOS), "Godot", templatesDir)
+	}
+	return ""
+}
+
+// DownloadExportTemplates downloads the export templates package for the given engine,
+// returning its path in the download cache.
+// The package is verified against the given SHA-512 checksum, or against the release's
+// SHA512-SUMS.txt when no checksum is given.
+func (d *Downloader) DownloadExportTemplates(targetOS TargetOS, engine GodotEngine, checksum string) (string, error) {
+	var fileName string = getExportTemplatesFileName(engine)
+	return d.downloadReleaseFile(targetOS, engine, fileName, checksum)
+}
+
+// ExportTemplatesURLs returns the name of the export templates package for the given engine,
+// along with its URL on each download source in the order they are tried.
+func (d *Downloader) ExportTemplatesURLs(engine GodotEngine) (string, []string, error) {
+	var fileName string = getExportTemplatesFileName(engine)
+	urls, err := d.releaseFileURLs(engine, fileName)
+	return fileName, urls, err
+}
+
+// ExportTemplatesInstallDir returns the directory the export templates of the given engine are installed to.
+func ExportTemplatesInstallDir(targetOS TargetOS, engine GodotEngine) string {
+	return filepath.Join(DefaultExportTemplatesDir(targetOS, engine.Version), ExportTemplatesVersion(engine))
+}
+
+// InstallExportTemplates unpacks an export templates package into the directory Godot
+// expects for the given engine, replacing any templates already there.
+func (d *Downloader) InstallExportTemplates(templatesPackage string, targetOS TargetOS, engine GodotEngine) (string, error) {
+	templatesDir := DefaultExportTemplatesDir(targetOS, engine.Version)
+	installDir := ExportTemplatesInstallDir(targetOS, engine)
+
+	if err := os.MkdirAll(templatesDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create export templates directory: %s", err)
+	}
+
+	// Unpack next to the install directory so it can be moved into place with a rename
+	stagingDir, err := os.MkdirTemp(templatesDir, ".gbt-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %s", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	if _, err := utils.UnzipTo(templatesPackage, stagingDir); err != nil {
+		return "", fmt.Errorf("failed to unzip export templates: %s", err)
+	}
+
+	// The package keeps its templates in a top-level "templates" directory
+	unpackedDir := filepath.Join(stagingDir, "templates")
+	if info, err := os.Stat(unpackedDir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("failed to find templates directory in export templates package")
+	}
+
+	if err := os.RemoveAll(installDir); err != nil {
+		return "", fmt.Errorf("failed to remove previous export templates: %s", err)
+	}
+	if err := os.Rename(unpackedDir, installDir); err != nil {
+		return "", fmt.Errorf("failed to install export templates: %s", err)
+	}
+
+	return installDir, nil
+}
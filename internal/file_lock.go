@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// fileLock is an exclusive lock held on a lock file.
+type fileLock struct {
+	file *os.File
+	path string
+}
+
+// waitFileLock takes the lock file at the given path, waiting up to the timeout for another
+// process holding it. waiting is called once if the lock has to be waited for.
+func waitFileLock(lockPath string, timeout time.Duration, waiting func()) (*fileLock, error) {
+	deadline := time.Now().Add(timeout)
+	var wait time.Duration = 100 * time.Millisecond
+	var warned bool
+
+	for {
+		lock, err := tryFileLock(lockPath)
+		if err != nil {
+			return nil, err
+		}
+		if lock != nil {
+			return lock, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock %s", lockPath)
+		}
+		if !warned {
+			waiting()
+			warned = true
+		}
+
+		time.Sleep(wait)
+		if wait < 5*time.Second {
+			wait *= 2
+		}
+	}
+}
+
+// tryFileLock takes the lock file at the given path without waiting. A nil lock is
+// returned if another process holds it.
+func tryFileLock(lockPath string) (*fileLock, error) {
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open lock: %s", err)
+		}
+
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %s", lockPath, err)
+		}
+		if !locked {
+			file.Close()
+			return nil, nil
+		}
+
+		// The previous holder removes the lock file when it's done, so the file we locked
+		// may no longer be the one other processes open
+		opened, err := file.Stat()
+		current, statErr := os.Stat(lockPath)
+		if err != nil || statErr != nil || !os.SameFile(opened, current) {
+			_ = unlockFile(file)
+			file.Close()
+			continue
+		}
+
+		// Record the holder for anyone looking into a job stuck waiting on it
+		_ = file.Truncate(0)
+		_, _ = fmt.Fprintf(file, "%d\n", os.Getpid())
+
+		return &fileLock{file: file, path: lockPath}, nil
+	}
+}
+
+// release removes the lock file and releases the lock. The file is removed while still
+// locked, so processes waiting on it notice and open a new one.
+func (l *fileLock) release() {
+	_ = os.Remove(l.path)
+	_ = unlockFile(l.file)
+	_ = l.file.Close()
+}
@@ -8,13 +8,27 @@ import (
 )
 
 type BuildFlags struct {
-	stepsRaw string
-	DebugLog bool
+	stepsRaw  string
+	DebugLog  bool
+	LogFormat string
+	Frozen    bool
+	Offline   bool
+	Pipeline  string
+	DryRun    bool
+	PlanJSON  string
+
+	stepsSet bool
 }
 
 // Steps returns the steps to run as a slice of strings
 func (f *BuildFlags) Steps() []string {
-	return strings.Split(f.stepsRaw, ",")
+	var steps []string = make([]string, 0)
+	for _, step := range strings.Split(f.stepsRaw, ",") {
+		if step = strings.TrimSpace(step); step != "" {
+			steps = append(steps, step)
+		}
+	}
+	return steps
 }
 
 // HasStep returns true if the given step is in the list of steps to run
@@ -31,14 +45,30 @@ func (f *BuildFlags) HasStep(step string) bool {
 // Parse parses the flags
 func (f *BuildFlags) Parse() {
 	flag.Parse()
+	flag.Visit(func(fl *flag.Flag) {
+		if fl.Name == "steps" {
+			f.stepsSet = true
+		}
+	})
+}
+
+// StepsSet returns true if -steps was given explicitly rather than left at its default
+func (f *BuildFlags) StepsSet() bool {
+	return f.stepsSet
 }
 
 // NewBuildFlags creates a new BuildFlags instance
 func NewBuildFlags(logger logging.Logger) *BuildFlags {
 	flags := &BuildFlags{}
 
-	flag.StringVar(&flags.stepsRaw, "steps", "godot-setup", "Comma-separated list of build steps to run")
+	flag.StringVar(&flags.stepsRaw, "steps", "godot-setup", "Comma-separated list of build steps to run (godot-setup, export-templates, import, export), their dependencies run as well")
 	flag.BoolVar(&flags.DebugLog, "verbose", false, "Enable debug logging")
+	flag.StringVar(&flags.LogFormat, "log-format", logging.FormatAuto, "Log format, one of "+strings.Join(logging.Formats, ", ")+", auto detects the CI system")
+	flag.BoolVar(&flags.Frozen, "frozen", false, "Fail if the lockfile is missing or out of date instead of updating it")
+	flag.BoolVar(&flags.Offline, "offline", false, "Refuse network access, using only vendored and cached packages")
+	flag.StringVar(&flags.Pipeline, "pipeline", "", "Name of a [[pipeline]] in the build config to run instead of -steps")
+	flag.BoolVar(&flags.DryRun, "dry-run", false, "Print what the build would do without downloading, installing or running anything")
+	flag.StringVar(&flags.PlanJSON, "plan-json", "", "Write what the build would do as JSON to the given file, or - for stdout, without running it")
 
 	return flags
 }
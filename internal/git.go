@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// branchEnvVars are set by CI providers to the branch being built, in order of preference.
+// Pull requests are checked out at a detached merge commit, so their source branch comes first.
+var branchEnvVars = []string{"GITHUB_HEAD_REF", "GITHUB_REF_NAME", "CI_COMMIT_BRANCH", "CI_COMMIT_REF_NAME"}
+
+// CurrentBranch returns the branch being built, from the CI environment or the git checkout in dir.
+// It returns an empty string if the branch can't be determined.
+func CurrentBranch(dir string) string {
+	for _, name := range branchEnvVars {
+		if branch := os.Getenv(name); branch != "" {
+			return branch
+		}
+	}
+
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	branch := strings.TrimSpace(string(output))
+	if branch == "HEAD" {
+		return ""
+	}
+	return branch
+}
@@ -4,15 +4,25 @@ import "strings"
 
 type DefaultGodotArgBuilder struct {
 	args []string
+	// godot3 selects the flags of Godot 3, which were renamed in Godot 4
+	godot3 bool
 }
 
-func NewGodotArgBuilder(projectDir string) GodotArgBuilder {
+// NewGodotArgBuilder creates an argument builder for the given version of Godot.
+func NewGodotArgBuilder(projectDir string, version string) GodotArgBuilder {
 	return &DefaultGodotArgBuilder{
-		args: []string{"--path", projectDir},
+		args:   []string{"--path", projectDir},
+		godot3: godotMajorVersion(version) == 3,
 	}
 }
 
+// AddHeadlessFlag runs Godot without a window, using --no-window on Godot 3. It only hides the
+// window on Windows and macOS, so Godot 3 is installed from its headless build on Linux.
 func (b *DefaultGodotArgBuilder) AddHeadlessFlag() {
+	if b.godot3 {
+		b.args = append(b.args, "--no-window")
+		return
+	}
 	b.args = append(b.args, "--headless")
 }
 
@@ -40,15 +50,39 @@ func (b *DefaultGodotArgBuilder) AddCheckOnlyFlag() {
 	b.args = append(b.args, "--check-only")
 }
 
-func (b *DefaultGodotArgBuilder) AddExportFlag(exportType ExportType) {
+func (b *DefaultGodotArgBuilder) AddEditorFlag() {
+	b.args = append(b.args, "--editor")
+}
+
+// AddImportFlag imports the project's resources and quits, supported since Godot 4.2.
+func (b *DefaultGodotArgBuilder) AddImportFlag() {
+	b.args = append(b.args, "--import")
+}
+
+func (b *DefaultGodotArgBuilder) AddQuitFlag() {
+	b.args = append(b.args, "--quit")
+}
+
+// AddExportFlag exports the given preset, Godot 3 exports release builds with --export.
+func (b *DefaultGodotArgBuilder) AddExportFlag(exportType ExportType, preset string, outputPath string) {
 	switch exportType {
 	case ExportTypeRelease:
-		b.args = append(b.args, "--export")
+		if b.godot3 {
+			b.args = append(b.args, "--export")
+			break
+		}
+		b.args = append(b.args, "--export-release")
 	case ExportTypeDebug:
 		b.args = append(b.args, "--export-debug")
 	case ExportTypePack:
 		b.args = append(b.args, "--export-pack")
 	}
+	b.args = append(b.args, preset, outputPath)
+}
+
+// Args returns a copy of the arguments, one per element, so values containing spaces are kept intact.
+func (b *DefaultGodotArgBuilder) Args() []string {
+	return append([]string{}, b.args...)
 }
 
 func (b *DefaultGodotArgBuilder) GenerateArgs() string {
@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GodotConfig holds the raw values of a Godot ConfigFile (project.godot, export_presets.cfg), keyed by section and key.
+// Keys that appear before the first section are stored under the empty section name.
+type GodotConfig map[string]map[string]string
+
+// LoadGodotConfig reads and parses a Godot ConfigFile from disk.
+func LoadGodotConfig(configPath string) (GodotConfig, error) {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := ParseGodotConfig(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", configPath, err)
+	}
+	return config, nil
+}
+
+// ParseGodotConfig parses the contents of a Godot ConfigFile.
+func ParseGodotConfig(content string) (GodotConfig, error) {
+	config := GodotConfig{"": {}}
+	section := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			if _, ok := config[section]; !ok {
+				config[section] = map[string]string{}
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected key=value", lineNumber)
+		}
+
+		// Values such as strings, arrays and dictionaries may span multiple lines
+		for !isGodotValueComplete(value) {
+			if !scanner.Scan() {
+				return nil, fmt.Errorf("line %d: unterminated value for %s", lineNumber, key)
+			}
+			lineNumber++
+			value += "\n" + scanner.Text()
+		}
+
+		config[section][strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// isGodotValueComplete returns true if all strings and brackets in the value have been closed.
+func isGodotValueComplete(value string) bool {
+	depth := 0
+	inString := false
+	escaped := false
+
+	for _, c := range value {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
+	}
+
+	return !inString && depth <= 0
+}
+
+// Raw returns the unparsed value of a key.
+func (c GodotConfig) Raw(section string, key string) (string, bool) {
+	values, ok := c[section]
+	if !ok {
+		return "", false
+	}
+	value, ok := values[key]
+	return value, ok
+}
+
+// String returns the value of a key as a string, removing any surrounding quotes.
+func (c GodotConfig) String(section string, key string) string {
+	value, ok := c.Raw(section, key)
+	if !ok {
+		return ""
+	}
+	return unquoteGodotString(value)
+}
+
+// Int returns the value of a key as an integer, or 0 if it is not set or not a number.
+func (c GodotConfig) Int(section string, key string) int {
+	value, ok := c.Raw(section, key)
+	if !ok {
+		return 0
+	}
+	i, _ := strconv.Atoi(value)
+	return i
+}
+
+// StringArray returns the value of a key as a list of strings.
+// Both PackedStringArray("a", "b") and plain ["a", "b"] arrays are supported.
+func (c GodotConfig) StringArray(section string, key string) []string {
+	value, ok := c.Raw(section, key)
+	if !ok {
+		return nil
+	}
+
+	start := strings.IndexAny(value, "([")
+	end := strings.LastIndexAny(value, ")]")
+	if start == -1 || end <= start {
+		return nil
+	}
+
+	var items []string = make([]string, 0)
+	var current strings.Builder
+	inString := false
+	escaped := false
+	for _, ch := range value[start+1 : end] {
+		if inString {
+			current.WriteRune(ch)
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\':
+				escaped = true
+			case ch == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch ch {
+		case '"':
+			inString = true
+			current.WriteRune(ch)
+		case ',':
+			items = append(items, unquoteGodotString(strings.TrimSpace(current.String())))
+			current.Reset()
+		default:
+			current.WriteRune(ch)
+		}
+	}
+	if last := strings.TrimSpace(current.String()); last != "" {
+		items = append(items, unquoteGodotString(last))
+	}
+
+	return items
+}
+
+// unquoteGodotString removes the quotes and escape sequences from a Godot string value.
+func unquoteGodotString(value string) string {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+
+	return value[1 : len(value)-1]
+}
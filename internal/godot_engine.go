@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const godotVersionTimeout = 30 * time.Second
+
+// GodotEngine identifies a build of the Godot engine.
+type GodotEngine struct {
+	Version string
+	Release string
+	// Mono selects the .NET build of the engine, used by C# projects.
+	Mono bool
+	// Custom marks a custom build of the engine, installed apart from the official release.
+	Custom bool
+}
+
+// String returns the engine's name, such as "4.2.1-stable", "4.2.1-stable-mono" or "4.2.1-stable-custom".
+func (e GodotEngine) String() string {
+	name := e.Version + "-" + e.Release
+	if e.Mono {
+		name += "-mono"
+	}
+	if e.Custom {
+		name += "-custom"
+	}
+	return name
+}
+
+// MajorVersion returns the major version number of the engine, such as 4 for "4.2.1".
+func (e GodotEngine) MajorVersion() int {
+	return godotMajorVersion(e.Version)
+}
+
+// godotMajorVersion returns the major version number of a Godot version, such as 4 for "4.2.1".
+func godotMajorVersion(version string) int {
+	major, _, _ := strings.Cut(version, ".")
+	n, _ := strconv.Atoi(major)
+	return n
+}
+
+// MatchesVersionString returns true if a version string reported by `godot --version`, such as
+// "4.2.1.stable.official.b09f793f5" or "4.2.1.stable.mono.custom_build", is of this engine.
+func (e GodotEngine) MatchesVersionString(reported string) bool {
+	parts := strings.Split(strings.TrimSpace(reported), ".")
+	expected := strings.Split(e.Version+"."+e.Release, ".")
+	if len(parts) < len(expected) {
+		return false
+	}
+
+	for i, part := range expected {
+		if parts[i] != part {
+			return false
+		}
+	}
+
+	// Mono builds name themselves right after the release
+	isMono := len(parts) > len(expected) && parts[len(expected)] == "mono"
+	return isMono == e.Mono
+}
+
+// GodotBinaryVersion runs `godot --version` and returns the version the binary reports.
+func GodotBinaryVersion(godotBin string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), godotVersionTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, godotBin, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s --version: %s", godotBin, err)
+	}
+
+	// Godot may print warnings before the version, which is always the last line
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1]), nil
+}
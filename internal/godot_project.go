@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const godotProjectFile = "project.godot"
+const godotExportPresetsFile = "export_presets.cfg"
+
+var engineVersionPattern = regexp.MustCompile(`^\d+\.\d+(\.\d+)?$`)
+
+// GodotProject holds the project settings relevant to building a Godot project.
+type GodotProject struct {
+	Dir           string
+	Name          string
+	ConfigVersion int
+	Features      []string
+}
+
+// ExportPreset holds an export preset from export_presets.cfg.
+type ExportPreset struct {
+	Name       string
+	Platform   string
+	ExportPath string
+}
+
+// LoadGodotProject loads the project.godot file from the given directory.
+func LoadGodotProject(dir string) (*GodotProject, error) {
+	config, err := LoadGodotConfig(filepath.Join(dir, godotProjectFile))
+	if err != nil {
+		return nil, err
+	}
+
+	return &GodotProject{
+		Dir:           dir,
+		Name:          config.String("application", "config/name"),
+		ConfigVersion: config.Int("", "config_version"),
+		Features:      config.StringArray("application", "config/features"),
+	}, nil
+}
+
+// EngineVersion returns the Godot engine version the project was made with.
+// Godot 4 projects list the version in config/features; older projects only have a config_version
+// to go on, so guessed is true when the version is a guess from the config_version.
+func (p *GodotProject) EngineVersion() (version string, guessed bool, err error) {
+	for _, feature := range p.Features {
+		if engineVersionPattern.MatchString(feature) {
+			return feature, false, nil
+		}
+	}
+
+	switch p.ConfigVersion {
+	case 5:
+		return "4.0", true, nil
+	case 4:
+		return "3.5.3", true, nil
+	}
+
+	return "", false, fmt.Errorf("unable to determine engine version from %s", godotProjectFile)
+}
+
+// UsesCSharp returns true if the project has C# enabled, and so needs a mono build of Godot.
+func (p *GodotProject) UsesCSharp() bool {
+	for _, feature := range p.Features {
+		if feature == "C#" {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadExportPresets loads the export presets from the export_presets.cfg file in the given directory.
+// A missing export_presets.cfg is not an error, and results in no presets.
+func LoadExportPresets(dir string) ([]ExportPreset, error) {
+	config, err := LoadGodotConfig(filepath.Join(dir, godotExportPresetsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []ExportPreset{}, nil
+		}
+		return nil, err
+	}
+
+	// Presets are stored as [preset.0], [preset.1], ... and must be kept in order
+	var indexes []int = make([]int, 0)
+	for section := range config {
+		index, found := strings.CutPrefix(section, "preset.")
+		if !found {
+			continue
+		}
+		if i, err := strconv.Atoi(index); err == nil {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.Ints(indexes)
+
+	var presets []ExportPreset = make([]ExportPreset, 0, len(indexes))
+	for _, i := range indexes {
+		section := fmt.Sprintf("preset.%d", i)
+		presets = append(presets, ExportPreset{
+			Name:       config.String(section, "name"),
+			Platform:   config.String(section, "platform"),
+			ExportPath: config.String(section, "export_path"),
+		})
+	}
+
+	return presets, nil
+}
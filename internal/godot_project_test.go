@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTestProject writes project.godot and, if it isn't empty, export_presets.cfg to a temporary directory.
+func writeTestProject(t *testing.T, project string, exportPresets string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, godotProjectFile), []byte(project), 0644); err != nil {
+		t.Fatalf("failed to write %s: %s", godotProjectFile, err)
+	}
+	if exportPresets != "" {
+		if err := os.WriteFile(filepath.Join(dir, godotExportPresetsFile), []byte(exportPresets), 0644); err != nil {
+			t.Fatalf("failed to write %s: %s", godotExportPresetsFile, err)
+		}
+	}
+	return dir
+}
+
+const godot4ProjectFixture = `; Engine configuration file.
+; It's best edited using the editor UI and not directly,
+; since the parameters that go here are not all obvious.
+;
+; Format:
+;   [section] ; section goes between []
+;   param=value ; assign values to parameters
+
+config_version=5
+
+[application]
+
+config/name="Space Game"
+run/main_scene="res://main.tscn"
+config/features=PackedStringArray("4.2", "C#", "Forward Plus")
+config/icon="res://icon.svg"
+
+[dotnet]
+
+project/assembly_name="Space Game"
+`
+
+const godot3ProjectFixture = `; Engine configuration file.
+
+config_version=4
+
+[application]
+
+config/name="Platformer"
+run/main_scene="res://Main.tscn"
+config/icon="res://icon.png"
+
+[rendering]
+
+environment/default_environment="res://default_env.tres"
+`
+
+func TestGodotProjectEngineVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		project  string
+		version  string
+		guessed  bool
+		csharp   bool
+		features []string
+	}{
+		{"Godot 4", godot4ProjectFixture, "4.2", false, true, []string{"4.2", "C#", "Forward Plus"}},
+		{"Godot 4 patch version", "config_version=5\n\n[application]\n\nconfig/features=PackedStringArray(\"Mobile\", \"4.2.1\")\n", "4.2.1", false, false, []string{"Mobile", "4.2.1"}},
+		{"Godot 4 without features", "config_version=5\n\n[application]\n\nconfig/name=\"Game\"\n", "4.0", true, false, nil},
+		{"Godot 3", godot3ProjectFixture, "3.5.3", true, false, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			project, err := LoadGodotProject(writeTestProject(t, test.project, ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			version, guessed, err := project.EngineVersion()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != test.version || guessed != test.guessed {
+				t.Errorf("expected version %s (guessed %v), got %s (guessed %v)", test.version, test.guessed, version, guessed)
+			}
+			if project.UsesCSharp() != test.csharp {
+				t.Errorf("expected UsesCSharp to be %v", test.csharp)
+			}
+			if len(test.features) > 0 && !reflect.DeepEqual(project.Features, test.features) {
+				t.Errorf("expected features %q, got %q", test.features, project.Features)
+			}
+		})
+	}
+}
+
+func TestGodotProjectEngineVersionUnknown(t *testing.T) {
+	project, err := LoadGodotProject(writeTestProject(t, "config_version=3\n\n[application]\n\nname=\"Old Game\"\n", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := project.EngineVersion(); err == nil {
+		t.Errorf("expected an error for a Godot 2 project")
+	}
+}
+
+func TestLoadExportPresets(t *testing.T) {
+	dir := writeTestProject(t, godot4ProjectFixture, `[preset.1]
+
+name="Windows Desktop"
+platform="Windows Desktop"
+runnable=true
+export_path="build/windows/Space Game.exe"
+
+[preset.1.options]
+
+binary_format/embed_pck=false
+
+[preset.0]
+
+name="Linux/X11"
+platform="Linux/X11"
+runnable=true
+export_path=""
+
+[preset.0.options]
+
+binary_format/embed_pck=false
+`)
+
+	presets, err := LoadExportPresets(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ExportPreset{
+		{Name: "Linux/X11", Platform: "Linux/X11"},
+		{Name: "Windows Desktop", Platform: "Windows Desktop", ExportPath: "build/windows/Space Game.exe"},
+	}
+	if !reflect.DeepEqual(presets, expected) {
+		t.Errorf("expected presets %+v, got %+v", expected, presets)
+	}
+
+	presets, err = LoadExportPresets(writeTestProject(t, godot3ProjectFixture, ""))
+	if err != nil || len(presets) != 0 {
+		t.Errorf("expected no presets without export_presets.cfg, got %+v, %v", presets, err)
+	}
+}
@@ -0,0 +1,270 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+const defaultTailLines = 20
+
+// godotWaitDelay is how long output is read after Godot exits or is killed, for processes
+// Godot started that still hold its stdout and stderr open.
+const godotWaitDelay = 5 * time.Second
+
+// GodotRunnerOptions holds options for creating a new Godot runner.
+type GodotRunnerOptions struct {
+	// Timeout is the maximum time a single run may take, zero means no timeout.
+	Timeout time.Duration
+	// Dir is the working directory Godot runs in.
+	Dir string
+	// TailLines is the number of output lines kept in the run result.
+	TailLines int
+}
+
+// GodotRunner runs a Godot binary and forwards its output to a logger.
+type GodotRunner struct {
+	godotBin  string
+	timeout   time.Duration
+	dir       string
+	tailLines int
+	waitDelay time.Duration
+
+	logger logging.Logger
+}
+
+// GodotRunResult holds the outcome of a Godot run.
+type GodotRunResult struct {
+	ExitCode int
+	Duration time.Duration
+	// Tail holds the last lines Godot wrote to stdout and stderr.
+	Tail []string
+}
+
+// NewGodotRunner creates a new runner for the given Godot binary.
+func NewGodotRunner(godotBin string, logger logging.Logger, options *GodotRunnerOptions) *GodotRunner {
+	var tailLines int = options.TailLines
+	if tailLines <= 0 {
+		tailLines = defaultTailLines
+	}
+
+	return &GodotRunner{
+		godotBin:  godotBin,
+		timeout:   options.Timeout,
+		dir:       options.Dir,
+		tailLines: tailLines,
+		waitDelay: godotWaitDelay,
+		logger:    logger,
+	}
+}
+
+// Run runs Godot with the given arguments, usually from GodotArgBuilder.Args.
+// An error is returned if Godot can't be started, times out, or exits with a non-zero code.
+func (r *GodotRunner) Run(ctx context.Context, args []string) (*GodotRunResult, error) {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	// Output is copied through writers rather than read from StdoutPipe, so Wait owns the
+	// pipes and closes them after WaitDelay when processes Godot started still hold them open
+	stdout, stdoutWriter := io.Pipe()
+	stderr, stderrWriter := io.Pipe()
+
+	cmd := exec.CommandContext(ctx, r.godotBin, args...)
+	cmd.Dir = r.dir
+	cmd.Stdout = stdoutWriter
+	cmd.Stderr = stderrWriter
+	cmd.WaitDelay = r.waitDelay
+
+	r.logger.Debugf("Running %s %s", r.godotBin, strings.Join(args, " "))
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start Godot: %s", err)
+	}
+
+	output := &godotOutput{logger: r.logger, tailLines: r.tailLines}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		output.forward(stdout, false)
+	}()
+	go func() {
+		defer wg.Done()
+		output.forward(stderr, true)
+	}()
+
+	err := cmd.Wait()
+	stdoutWriter.Close()
+	stderrWriter.Close()
+	wg.Wait()
+
+	if errors.Is(err, exec.ErrWaitDelay) {
+		r.logger.Warnf("Godot exited, but processes it started kept its output open")
+		err = nil
+	}
+
+	result := &GodotRunResult{
+		ExitCode: cmd.ProcessState.ExitCode(),
+		Duration: time.Since(start),
+		Tail:     output.tail,
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return result, fmt.Errorf("godot timed out after %s", r.timeout)
+	}
+	if ctx.Err() == context.Canceled {
+		return result, fmt.Errorf("godot was cancelled")
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return result, fmt.Errorf("godot exited with code %d", result.ExitCode)
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to run Godot: %s", err)
+	}
+
+	return result, nil
+}
+
+// godotOutput forwards Godot's output to a logger while keeping the last lines.
+type godotOutput struct {
+	mu        sync.Mutex
+	logger    logging.Logger
+	tail      []string
+	tailLines int
+}
+
+// godotLocation matches the location of a GDScript error, such as "at: GDScript::reload (res://main.gd:12)"
+// in Godot 4 or "At: res://main.gd:12:_ready()" in Godot 3.
+var godotLocation = regexp.MustCompile(`(?i)^at:.*?(res://[^\s:()]+):(\d+)`)
+
+// godotIssue is an error or warning Godot printed, waiting for the line with its location.
+type godotIssue struct {
+	line    string
+	kind    string
+	message string
+	isError bool
+}
+
+// forward logs each line read from the reader until it is closed.
+// Lines on stderr are logged at the level of Godot's ERROR/WARNING prefixes, and the indented
+// lines that follow a prefixed line (such as the "at:" location) share its level. Any other
+// line on stderr is logged as a warning. Errors and warnings in the project's scripts are
+// annotated at their location.
+func (o *godotOutput) forward(reader io.Reader, isStderr bool) {
+	var defaultLogf func(format string, args ...interface{}) = o.logger.Infof
+	if isStderr {
+		defaultLogf = o.logger.Warnf
+	}
+	var logf func(format string, args ...interface{}) = defaultLogf
+
+	var pending *godotIssue
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if isStderr {
+			trimmed := strings.TrimSpace(line)
+			if pending != nil {
+				issue := pending
+				pending = nil
+				if match := godotLocation.FindStringSubmatch(trimmed); match != nil {
+					lineNumber, _ := strconv.Atoi(match[2])
+					o.annotate(issue, match[1], lineNumber)
+					// The annotation replaces the prefixed line, its location is logged at the same level
+					o.log(logf, line)
+					continue
+				}
+				o.log(logf, issue.line)
+			}
+
+			for _, prefix := range []string{"ERROR:", "SCRIPT ERROR:", "USER ERROR:", "WARNING:", "USER WARNING:"} {
+				if strings.HasPrefix(trimmed, prefix) {
+					var isError bool = strings.Contains(prefix, "ERROR")
+					logf = o.logger.Warnf
+					if isError {
+						logf = o.logger.Errorf
+					}
+
+					// Held back until the next line shows whether it points into the project
+					pending = &godotIssue{
+						line:    line,
+						kind:    strings.TrimSuffix(prefix, ":"),
+						message: strings.TrimSpace(strings.TrimPrefix(trimmed, prefix)),
+						isError: isError,
+					}
+					break
+				}
+			}
+			if pending != nil {
+				continue
+			}
+
+			// Only the indented lines that follow a prefixed line belong to it
+			if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+				logf = defaultLogf
+			}
+		}
+
+		o.log(logf, line)
+	}
+
+	if pending != nil {
+		o.log(logf, pending.line)
+	}
+
+	// Keep Godot from blocking on a full pipe if a line was too long to scan
+	_, _ = io.Copy(io.Discard, reader)
+}
+
+// log logs a line of output and keeps it in the tail.
+func (o *godotOutput) log(logf func(format string, args ...interface{}), line string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	logf("%s", line)
+	o.appendTail(line)
+}
+
+// annotate reports an error or warning at its location in the project.
+func (o *godotOutput) annotate(issue *godotIssue, file string, line int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	input := logging.NoticeMessageInput{
+		Title:    &issue.kind,
+		Filename: &file,
+		Line:     &line,
+	}
+	if issue.isError {
+		o.logger.ErrorAnnotation(issue.message, input)
+	} else {
+		o.logger.WarningAnnotation(issue.message, input)
+	}
+	o.appendTail(issue.line)
+}
+
+// appendTail keeps a line in the tail, dropping the oldest line once the tail is full.
+func (o *godotOutput) appendTail(line string) {
+	o.tail = append(o.tail, line)
+	if len(o.tail) > o.tailLines {
+		o.tail = o.tail[len(o.tail)-o.tailLines:]
+	}
+}
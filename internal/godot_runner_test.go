@@ -0,0 +1,320 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+// recordedLine is a message logged by recordingLogger.
+type recordedLine struct {
+	level   string
+	message string
+}
+
+// recordingLogger is a logger that keeps every message it is given.
+type recordingLogger struct {
+	mu    sync.Mutex
+	lines []recordedLine
+	masks []string
+}
+
+func (l *recordingLogger) record(level string, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, recordedLine{level: level, message: message})
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {
+	l.record("info", fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Warnf(format string, args ...interface{}) {
+	l.record("warning", fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Errorf(format string, args ...interface{}) {
+	l.record("error", fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.record("debug", fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) NoticeMessage(message string, input logging.NoticeMessageInput) {
+	l.record("notice-annotation", message)
+}
+
+func (l *recordingLogger) WarningAnnotation(message string, input logging.NoticeMessageInput) {
+	l.record("warning-annotation", fmt.Sprintf("%s:%d: %s", *input.Filename, *input.Line, message))
+}
+
+func (l *recordingLogger) ErrorAnnotation(message string, input logging.NoticeMessageInput) {
+	l.record("error-annotation", fmt.Sprintf("%s:%d: %s", *input.Filename, *input.Line, message))
+}
+
+func (l *recordingLogger) Mask(value string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.masks = append(l.masks, value)
+}
+
+func (l *recordingLogger) StartGroup(name string)              {}
+func (l *recordingLogger) EndGroup()                           {}
+func (l *recordingLogger) SetOutput(name string, value string) {}
+func (l *recordingLogger) SetSummary(summary string)           {}
+
+// levels returns the level each message was logged at, leaving out debug messages.
+func (l *recordingLogger) levels() map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	levels := map[string]string{}
+	for _, line := range l.lines {
+		if line.level != "debug" {
+			levels[line.message] = line.level
+		}
+	}
+	return levels
+}
+
+// messages returns the messages logged at the given level.
+func (l *recordingLogger) messages(level string) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var messages []string
+	for _, line := range l.lines {
+		if line.level == level {
+			messages = append(messages, line.message)
+		}
+	}
+	return messages
+}
+
+// fakeGodot writes a shell script standing in for the Godot binary.
+func fakeGodot(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake Godot binaries are shell scripts")
+	}
+
+	path := filepath.Join(t.TempDir(), "godot")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("failed to write fake Godot: %s", err)
+	}
+	return path
+}
+
+func TestGodotRunnerNonZeroExit(t *testing.T) {
+	godotBin := fakeGodot(t, `
+for i in 1 2 3 4 5; do echo "line $i"; done
+exit 3
+`)
+	logger := &recordingLogger{}
+	runner := NewGodotRunner(godotBin, logger, &GodotRunnerOptions{TailLines: 3})
+
+	result, err := runner.Run(context.Background(), nil)
+	if err == nil || err.Error() != "godot exited with code 3" {
+		t.Fatalf("expected exit code error, got %v", err)
+	}
+	if result.ExitCode != 3 {
+		t.Errorf("expected exit code 3, got %d", result.ExitCode)
+	}
+
+	var expected []string = []string{"line 3", "line 4", "line 5"}
+	if strings.Join(result.Tail, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected tail %q, got %q", expected, result.Tail)
+	}
+}
+
+func TestGodotRunnerTimeout(t *testing.T) {
+	godotBin := fakeGodot(t, `
+echo "starting"
+exec sleep 5
+`)
+	logger := &recordingLogger{}
+	runner := NewGodotRunner(godotBin, logger, &GodotRunnerOptions{Timeout: 200 * time.Millisecond})
+
+	start := time.Now()
+	result, err := runner.Run(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("expected Godot to be killed at the timeout, took %s", elapsed)
+	}
+	if len(result.Tail) != 1 || result.Tail[0] != "starting" {
+		t.Errorf("expected tail [starting], got %q", result.Tail)
+	}
+}
+
+func TestGodotRunnerTimeoutWithChildProcesses(t *testing.T) {
+	// The background sleep keeps stdout and stderr open after Godot is killed
+	godotBin := fakeGodot(t, `
+echo "starting"
+sleep 5 &
+sleep 5
+`)
+	logger := &recordingLogger{}
+	runner := NewGodotRunner(godotBin, logger, &GodotRunnerOptions{Timeout: 200 * time.Millisecond})
+	runner.waitDelay = 100 * time.Millisecond
+
+	start := time.Now()
+	result, err := runner.Run(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("expected the run to end at the timeout, took %s", elapsed)
+	}
+	if len(result.Tail) != 1 || result.Tail[0] != "starting" {
+		t.Errorf("expected tail [starting], got %q", result.Tail)
+	}
+}
+
+func TestGodotRunnerExitWithChildProcesses(t *testing.T) {
+	godotBin := fakeGodot(t, `
+echo "exported"
+sleep 5 &
+exit 0
+`)
+	logger := &recordingLogger{}
+	runner := NewGodotRunner(godotBin, logger, &GodotRunnerOptions{})
+	runner.waitDelay = 100 * time.Millisecond
+
+	start := time.Now()
+	result, err := runner.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("expected Godot's exit code to decide the result, got %s", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("expected the run to end when Godot exits, took %s", elapsed)
+	}
+	if result.ExitCode != 0 || len(result.Tail) != 1 || result.Tail[0] != "exported" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestGodotRunnerLogLevels(t *testing.T) {
+	godotBin := fakeGodot(t, `
+echo "Godot Engine v4.2.1.stable"
+echo "ERROR: on stdout"
+echo "ERROR: Condition failed." >&2
+echo "   at: load (core/io/resource.cpp:12)" >&2
+echo "plain stderr" >&2
+echo "WARNING: Deprecated setting." >&2
+echo "   continued" >&2
+echo "after warning" >&2
+`)
+	logger := &recordingLogger{}
+	runner := NewGodotRunner(godotBin, logger, &GodotRunnerOptions{})
+
+	result, err := runner.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	expected := map[string]string{
+		"Godot Engine v4.2.1.stable":            "info",
+		"ERROR: on stdout":                      "info",
+		"ERROR: Condition failed.":              "error",
+		"   at: load (core/io/resource.cpp:12)": "error",
+		"plain stderr":                          "warning",
+		"WARNING: Deprecated setting.":          "warning",
+		"   continued":                          "warning",
+		"after warning":                         "warning",
+	}
+	levels := logger.levels()
+	for message, level := range expected {
+		if levels[message] != level {
+			t.Errorf("expected %q to be logged as %s, got %q", message, level, levels[message])
+		}
+	}
+
+	// Both streams are read at once, so only the set of lines in the tail is stable
+	var tail []string = append([]string{}, result.Tail...)
+	sort.Strings(tail)
+	var lines []string
+	for message := range expected {
+		lines = append(lines, message)
+	}
+	sort.Strings(lines)
+	if strings.Join(tail, "\n") != strings.Join(lines, "\n") {
+		t.Errorf("expected tail %q, got %q", lines, tail)
+	}
+}
+
+func TestGodotRunnerResetsLevelAfterError(t *testing.T) {
+	godotBin := fakeGodot(t, `
+echo "ERROR: Condition failed." >&2
+echo "   at: load (core/io/resource.cpp:12)" >&2
+echo "Loading scene" >&2
+echo "WARNING: Deprecated setting." >&2
+`)
+	logger := &recordingLogger{}
+	runner := NewGodotRunner(godotBin, logger, &GodotRunnerOptions{})
+
+	if _, err := runner.Run(context.Background(), nil); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	levels := logger.levels()
+	if levels["Loading scene"] != "warning" {
+		t.Errorf("expected an unprefixed line after an error to be a warning, got %q", levels["Loading scene"])
+	}
+	if levels["WARNING: Deprecated setting."] != "warning" {
+		t.Errorf("expected WARNING line to be a warning, got %q", levels["WARNING: Deprecated setting."])
+	}
+}
+
+func TestGodotRunnerAnnotatesScriptErrors(t *testing.T) {
+	godotBin := fakeGodot(t, `
+echo "SCRIPT ERROR: Invalid call." >&2
+echo "   at: _ready (res://main.gd:12)" >&2
+echo "USER WARNING: Unused variable." >&2
+echo "   at: _process (res://player.gd:40)" >&2
+`)
+	logger := &recordingLogger{}
+	runner := NewGodotRunner(godotBin, logger, &GodotRunnerOptions{})
+
+	result, err := runner.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+
+	expected := map[string]string{
+		"res://main.gd:12: Invalid call.":      "error-annotation",
+		"   at: _ready (res://main.gd:12)":     "error",
+		"res://player.gd:40: Unused variable.": "warning-annotation",
+		"   at: _process (res://player.gd:40)": "warning",
+	}
+	levels := logger.levels()
+	for message, level := range expected {
+		if levels[message] != level {
+			t.Errorf("expected %q to be logged as %s, got %q", message, level, levels[message])
+		}
+	}
+	if _, ok := levels["SCRIPT ERROR: Invalid call."]; ok {
+		t.Errorf("expected the annotated line not to be logged again")
+	}
+
+	var expectedTail []string = []string{
+		"SCRIPT ERROR: Invalid call.",
+		"   at: _ready (res://main.gd:12)",
+		"USER WARNING: Unused variable.",
+		"   at: _process (res://player.gd:40)",
+	}
+	if strings.Join(result.Tail, "\n") != strings.Join(expectedTail, "\n") {
+		t.Errorf("expected tail %q, got %q", expectedTail, result.Tail)
+	}
+}
@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGodotArgBuilder(t *testing.T) {
+	tests := []struct {
+		version    string
+		exportType ExportType
+		expected   []string
+	}{
+		{"4.2.1", ExportTypeRelease, []string{"--path", "project dir", "--headless", "--export-release", "Linux/X11", "build/game.x86_64"}},
+		{"4.2.1", ExportTypeDebug, []string{"--path", "project dir", "--headless", "--export-debug", "Linux/X11", "build/game.x86_64"}},
+		{"4.2.1", ExportTypePack, []string{"--path", "project dir", "--headless", "--export-pack", "Linux/X11", "build/game.x86_64"}},
+		{"3.5.3", ExportTypeRelease, []string{"--path", "project dir", "--no-window", "--export", "Linux/X11", "build/game.x86_64"}},
+		{"3.5.3", ExportTypeDebug, []string{"--path", "project dir", "--no-window", "--export-debug", "Linux/X11", "build/game.x86_64"}},
+		{"3.5.3", ExportTypePack, []string{"--path", "project dir", "--no-window", "--export-pack", "Linux/X11", "build/game.x86_64"}},
+	}
+
+	for _, test := range tests {
+		args := NewGodotArgBuilder("project dir", test.version)
+		args.AddHeadlessFlag()
+		args.AddExportFlag(test.exportType, "Linux/X11", "build/game.x86_64")
+
+		if got := args.Args(); strings.Join(got, "\x00") != strings.Join(test.expected, "\x00") {
+			t.Errorf("%s %v: expected %q, got %q", test.version, test.exportType, test.expected, got)
+		}
+	}
+}
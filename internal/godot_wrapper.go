@@ -1,5 +1,7 @@
 package internal
 
+import "fmt"
+
 type ExportType uint8
 
 const (
@@ -8,6 +10,31 @@ const (
 	ExportTypePack
 )
 
+func (t ExportType) String() string {
+	switch t {
+	case ExportTypeRelease:
+		return "release"
+	case ExportTypeDebug:
+		return "debug"
+	case ExportTypePack:
+		return "pack"
+	}
+	return ""
+}
+
+// ParseExportType returns the export type for the given name, defaulting to release when empty.
+func ParseExportType(name string) (ExportType, error) {
+	switch name {
+	case "", "release":
+		return ExportTypeRelease, nil
+	case "debug":
+		return ExportTypeDebug, nil
+	case "pack":
+		return ExportTypePack, nil
+	}
+	return ExportTypeRelease, fmt.Errorf("unknown export type %q", name)
+}
+
 type GodotArgBuilder interface {
 	AddHeadlessFlag()
 	AddDebugFlag()
@@ -18,7 +45,12 @@ type GodotArgBuilder interface {
 	AddDumpExtensionApiFlag()
 	AddCheckOnlyFlag()
 
-	AddExportFlag(exportType ExportType)
+	AddEditorFlag()
+	AddImportFlag()
+	AddQuitFlag()
+
+	AddExportFlag(exportType ExportType, preset string, outputPath string)
 
+	Args() []string
 	GenerateArgs() string
 }
@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yeslayla/godot-build-tools/utils"
+)
+
+const defaultDownloadRetries = 3
+const downloadRetryBaseDelay = 2 * time.Second
+const downloadRetryMaxDelay = 30 * time.Second
+const downloadStallTimeout = 60 * time.Second
+const downloadProgressInterval = 5 * time.Second
+
+// permanentDownloadError is a download failure that retrying won't fix, such as a 404.
+type permanentDownloadError struct {
+	err error
+}
+
+func (e *permanentDownloadError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentDownloadError) Unwrap() error {
+	return e.err
+}
+
+// NetworkError is a download failure caused by the network or the server rather than by what
+// was requested, such as a dropped connection, a timeout or a 5xx response.
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// downloadFile downloads a URL to the output file, verifying it against the expected SHA-512 checksum.
+// Failed attempts are retried with exponential backoff, resuming from the partial file when the
+// server supports ranged requests. A partial file left by an earlier run is resumed as well.
+func (d *Downloader) downloadFile(downloadURL string, outFile string, checksum string, headers http.Header) error {
+	ctx := context.Background()
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+
+	var err error
+	for attempt := 0; attempt <= d.retries; attempt++ {
+		if attempt > 0 {
+			delay := d.retryDelay << (attempt - 1)
+			if delay > downloadRetryMaxDelay {
+				delay = downloadRetryMaxDelay
+			}
+			d.logger.Warnf("Download failed: %s, retrying in %s (attempt %d of %d)", err, delay, attempt+1, d.retries+1)
+
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return &NetworkError{fmt.Errorf("failed to download %s: timed out after %s", downloadURL, d.timeout)}
+			}
+		}
+
+		err = d.downloadAttempt(ctx, downloadURL, outFile, headers)
+		if err == nil {
+			break
+		}
+
+		var permanent *permanentDownloadError
+		if errors.As(err, &permanent) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return &NetworkError{fmt.Errorf("failed to download %s: timed out after %s", downloadURL, d.timeout)}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	actual, err := FileSHA512(outFile)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %s", outFile, err)
+	}
+	if err := verifyChecksum(path.Base(downloadURL), checksum, actual); err != nil {
+		// A corrupt partial file must not be resumed by the next run
+		_ = os.Remove(outFile)
+		return err
+	}
+
+	return nil
+}
+
+// downloadAttempt makes a single attempt at downloading a URL, appending to the output file if it exists.
+func (d *Downloader) downloadAttempt(ctx context.Context, downloadURL string, outFile string, headers http.Header) error {
+	out, err := os.OpenFile(outFile, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return &permanentDownloadError{fmt.Errorf("failed to open output file: %s", err)}
+	}
+	defer out.Close()
+
+	info, err := out.Stat()
+	if err != nil {
+		return &permanentDownloadError{fmt.Errorf("failed to stat output file: %s", err)}
+	}
+	var offset int64 = info.Size()
+
+	// Cancel the attempt if the connection stalls, so it can be retried
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stall := time.AfterFunc(d.stallTimeout, cancel)
+	defer stall.Stop()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return &permanentDownloadError{fmt.Errorf("failed to create request: %s", err)}
+	}
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		d.logger.Infof("Resuming download of %s from %s", path.Base(downloadURL), utils.FormatBytes(offset))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return d.attemptError(ctx, downloadURL, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		if start := contentRangeStart(resp.Header.Get("Content-Range")); start != offset {
+			// The partial file can't be trusted to line up with what the server sends, so the
+			// next attempt starts over
+			if err := out.Truncate(0); err != nil {
+				return &permanentDownloadError{fmt.Errorf("failed to truncate output file: %s", err)}
+			}
+			return &NetworkError{fmt.Errorf("server resumed %s from byte %d instead of %d, restarting the download", downloadURL, start, offset)}
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial file is already complete, the checksum will tell if it's correct
+		return nil
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		// The server ignored the range, so start over
+		offset = 0
+		if err := out.Truncate(0); err != nil {
+			return &permanentDownloadError{fmt.Errorf("failed to truncate output file: %s", err)}
+		}
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return &NetworkError{fmt.Errorf("failed to download %s: %s", downloadURL, resp.Status)}
+	default:
+		return &permanentDownloadError{fmt.Errorf("failed to download %s: %s", downloadURL, resp.Status)}
+	}
+
+	if _, err := out.Seek(offset, io.SeekStart); err != nil {
+		return &permanentDownloadError{fmt.Errorf("failed to seek output file: %s", err)}
+	}
+
+	var total int64 = -1
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	progress := &downloadProgress{
+		reader:       resp.Body,
+		stall:        stall,
+		stallTimeout: d.stallTimeout,
+		name:         path.Base(downloadURL),
+		written:      offset,
+		total:        total,
+		started:      time.Now(),
+		logged:       time.Now(),
+		resumed:      offset,
+		logger:       d.logger,
+		interval:     downloadProgressInterval,
+	}
+
+	if _, err := io.Copy(out, progress); err != nil {
+		return d.attemptError(ctx, downloadURL, err)
+	}
+	progress.log()
+
+	return nil
+}
+
+// attemptError returns the error for a failed request, reporting a stalled connection as such
+// rather than as a cancelled request.
+func (d *Downloader) attemptError(ctx context.Context, downloadURL string, err error) error {
+	if ctx.Err() != nil {
+		return &NetworkError{fmt.Errorf("failed to download %s: no data received for %s", downloadURL, d.stallTimeout)}
+	}
+	return &NetworkError{fmt.Errorf("failed to download %s: %s", downloadURL, err)}
+}
+
+// contentRangeStart returns the first byte of a Content-Range header such as "bytes 100-199/200".
+func contentRangeStart(contentRange string) int64 {
+	byteRange, _, _ := strings.Cut(strings.TrimPrefix(contentRange, "bytes "), "/")
+	start, _, _ := strings.Cut(byteRange, "-")
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,288 @@
+package internal
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testPackage is the content served by the test servers.
+var testPackage = []byte(strings.Repeat("godot package ", 1000))
+
+// testPackageChecksum returns the SHA-512 of testPackage.
+func testPackageChecksum() string {
+	sum := sha512.Sum512(testPackage)
+	return hex.EncodeToString(sum[:])
+}
+
+// recordedRequests keeps the Range header of every request a test server receives.
+type recordedRequests struct {
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (r *recordedRequests) add(req *http.Request) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ranges = append(r.ranges, req.Header.Get("Range"))
+	return len(r.ranges)
+}
+
+func (r *recordedRequests) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.ranges...)
+}
+
+// serveRange serves testPackage from the given offset as a partial response.
+func serveRange(w http.ResponseWriter, offset int) {
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(testPackage)-1, len(testPackage)))
+	w.Header().Set("Content-Length", fmt.Sprint(len(testPackage)-offset))
+	w.WriteHeader(http.StatusPartialContent)
+	_, _ = w.Write(testPackage[offset:])
+}
+
+func TestDownloadFileResumesDroppedConnection(t *testing.T) {
+	var half int = len(testPackage) / 2
+	requests := &recordedRequests{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.add(r) == 1 {
+			// Drop the connection half way through the body
+			w.Header().Set("Content-Length", fmt.Sprint(len(testPackage)))
+			_, _ = w.Write(testPackage[:half])
+			w.(http.Flusher).Flush()
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		serveRange(w, half)
+	}))
+	defer server.Close()
+
+	downloader := newTestDownloader(t)
+	downloader.retries = 1
+	downloader.retryDelay = time.Millisecond
+	outFile := filepath.Join(t.TempDir(), "package.zip")
+
+	if err := downloader.downloadFile(server.URL+"/package.zip", outFile, testPackageChecksum(), nil); err != nil {
+		t.Fatalf("expected the download to resume, got %s", err)
+	}
+
+	var expected []string = []string{"", fmt.Sprintf("bytes=%d-", half)}
+	if got := requests.get(); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected Range headers %q, got %q", expected, got)
+	}
+	assertTestPackage(t, outFile)
+}
+
+func TestDownloadFileServerIgnoresRange(t *testing.T) {
+	requests := &recordedRequests{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.add(r)
+		_, _ = w.Write(testPackage)
+	}))
+	defer server.Close()
+
+	downloader := newTestDownloader(t)
+	outFile := filepath.Join(t.TempDir(), "package.zip")
+	if err := os.WriteFile(outFile, []byte("left by an earlier run"), 0644); err != nil {
+		t.Fatalf("failed to write partial file: %s", err)
+	}
+
+	if err := downloader.downloadFile(server.URL+"/package.zip", outFile, testPackageChecksum(), nil); err != nil {
+		t.Fatalf("expected the download to start over, got %s", err)
+	}
+
+	if got := requests.get(); len(got) != 1 || got[0] != "bytes=22-" {
+		t.Errorf("expected a single ranged request, got %q", got)
+	}
+	assertTestPackage(t, outFile)
+}
+
+func TestDownloadFileRestartsOnContentRangeMismatch(t *testing.T) {
+	requests := &recordedRequests{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			requests.add(r)
+			// Resume from the wrong byte
+			serveRange(w, 10)
+			return
+		}
+		requests.add(r)
+		_, _ = w.Write(testPackage)
+	}))
+	defer server.Close()
+
+	downloader := newTestDownloader(t)
+	downloader.retries = 1
+	downloader.retryDelay = time.Millisecond
+	outFile := filepath.Join(t.TempDir(), "package.zip")
+	if err := os.WriteFile(outFile, testPackage[:100], 0644); err != nil {
+		t.Fatalf("failed to write partial file: %s", err)
+	}
+
+	if err := downloader.downloadFile(server.URL+"/package.zip", outFile, testPackageChecksum(), nil); err != nil {
+		t.Fatalf("expected the download to restart, got %s", err)
+	}
+
+	var expected []string = []string{"bytes=100-", ""}
+	if got := requests.get(); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected Range headers %q, got %q", expected, got)
+	}
+	assertTestPackage(t, outFile)
+}
+
+func TestDownloadFileStallTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", fmt.Sprint(len(testPackage)))
+		_, _ = w.Write(testPackage[:100])
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	downloader := newTestDownloader(t)
+	downloader.stallTimeout = 100 * time.Millisecond
+	outFile := filepath.Join(t.TempDir(), "package.zip")
+
+	start := time.Now()
+	err := downloader.downloadFile(server.URL+"/package.zip", outFile, testPackageChecksum(), nil)
+	if err == nil || !strings.Contains(err.Error(), "no data received for 100ms") {
+		t.Fatalf("expected a stall error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the stalled download to be cancelled, took %s", elapsed)
+	}
+
+	// What was received is kept for the next attempt to resume
+	if info, err := os.Stat(outFile); err != nil || info.Size() != 100 {
+		t.Errorf("expected 100 bytes to be kept, got %v %v", info, err)
+	}
+}
+
+func TestDownloadFileDoesNotRetryClientErrors(t *testing.T) {
+	requests := &recordedRequests{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.add(r)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	downloader := newTestDownloader(t)
+	downloader.retries = 3
+	downloader.retryDelay = time.Millisecond
+	outFile := filepath.Join(t.TempDir(), "package.zip")
+
+	err := downloader.downloadFile(server.URL+"/package.zip", outFile, testPackageChecksum(), nil)
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Fatalf("expected a 404 error, got %v", err)
+	}
+	if got := requests.get(); len(got) != 1 {
+		t.Errorf("expected a single request, got %d", len(got))
+	}
+}
+
+func TestDownloadFileRetriesServerErrors(t *testing.T) {
+	requests := &recordedRequests{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.add(r) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(testPackage)
+	}))
+	defer server.Close()
+
+	downloader := newTestDownloader(t)
+	downloader.retries = 3
+	downloader.retryDelay = time.Millisecond
+	outFile := filepath.Join(t.TempDir(), "package.zip")
+
+	if err := downloader.downloadFile(server.URL+"/package.zip", outFile, testPackageChecksum(), nil); err != nil {
+		t.Fatalf("expected the download to succeed after retrying, got %s", err)
+	}
+	if got := requests.get(); len(got) != 3 {
+		t.Errorf("expected 3 requests, got %d", len(got))
+	}
+	assertTestPackage(t, outFile)
+}
+
+// assertTestPackage fails the test if the file doesn't hold testPackage.
+func assertTestPackage(t *testing.T, filePath string) {
+	t.Helper()
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("failed to read download: %s", err)
+	}
+	if string(content) != string(testPackage) {
+		t.Errorf("expected the downloaded file to match the package, got %d bytes", len(content))
+	}
+}
+
+func TestDownloadFileChecksumMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("corrupt package"))
+	}))
+	defer server.Close()
+
+	downloader := newTestDownloader(t)
+	outFile := filepath.Join(t.TempDir(), "package.zip")
+
+	err := downloader.downloadFile(server.URL+"/package.zip", outFile, testPackageChecksum(), nil)
+	var checksumErr *ChecksumError
+	if !errors.As(err, &checksumErr) {
+		t.Fatalf("expected a checksum error, got %v", err)
+	}
+	if checksumErr.FileName != "package.zip" || checksumErr.Expected != testPackageChecksum() {
+		t.Errorf("unexpected checksum error: %s", err)
+	}
+	if _, err := os.Stat(outFile); !os.IsNotExist(err) {
+		t.Errorf("expected the corrupt download to be removed, got %v", err)
+	}
+}
+
+func TestDownloadFileNetworkErrors(t *testing.T) {
+	tests := []struct {
+		status  int
+		network bool
+	}{
+		{http.StatusServiceUnavailable, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusNotFound, false},
+		{http.StatusForbidden, false},
+	}
+
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+		}))
+
+		downloader := newTestDownloader(t)
+		outFile := filepath.Join(t.TempDir(), "package.zip")
+
+		err := downloader.downloadFile(server.URL+"/package.zip", outFile, testPackageChecksum(), nil)
+		server.Close()
+
+		var networkErr *NetworkError
+		if errors.As(err, &networkErr) != test.network {
+			t.Errorf("status %d: expected network error to be %t, got %v", test.status, test.network, err)
+		}
+	}
+}
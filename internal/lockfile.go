@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+const LockFile = ".godot-build.lock"
+
+const (
+	LockPackageEditor    = "editor"
+	LockPackageTemplates = "templates"
+)
+
+// lockPlatforms lists every platform recorded in a lockfile.
+var lockPlatforms = []struct {
+	os    TargetOS
+	archs []TargetArch
+}{
+	{TargetOSLinux, []TargetArch{TargetArchX86_64, TargetArchX86_32, TargetArchARM64, TargetArchARM32}},
+	{TargetOSWindows, []TargetArch{TargetArchX86_64, TargetArchX86_32, TargetArchARM64}},
+	{TargetOSMacOS, []TargetArch{TargetArchUniversal}},
+}
+
+// Lockfile records the exact Godot packages installed for a build config.
+type Lockfile struct {
+	Godot    LockfileGodot     `toml:"godot"`
+	Packages []LockfilePackage `toml:"package"`
+}
+
+// LockfileGodot holds the [godot] settings the lockfile was generated from, and what they resolved to.
+type LockfileGodot struct {
+	Version         string `toml:"version"`
+	Release         string `toml:"release"`
+	Mono            bool   `toml:"mono"`
+	ResolvedVersion string `toml:"resolved_version"`
+	ResolvedRelease string `toml:"resolved_release"`
+}
+
+// LockfilePackage records a single downloadable package.
+type LockfilePackage struct {
+	Kind   string `toml:"kind"`
+	OS     string `toml:"os,omitempty"`
+	Arch   string `toml:"arch,omitempty"`
+	File   string `toml:"file"`
+	URL    string `toml:"url"`
+	SHA512 string `toml:"sha512"`
+}
+
+// LoadLockfile reads a lockfile from disk.
+func LoadLockfile(lockPath string) (*Lockfile, error) {
+	content, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &Lockfile{}
+	if _, err := toml.Decode(string(content), lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", lockPath, err)
+	}
+	return lock, nil
+}
+
+// Write writes the lockfile to disk.
+func (l *Lockfile) Write(lockPath string) error {
+	var buf bytes.Buffer
+	buf.WriteString("# This file is generated by Godot Build Tools, do not edit it by hand.\n\n")
+	encoder := toml.NewEncoder(&buf)
+	encoder.Indent = ""
+	if err := encoder.Encode(l); err != nil {
+		return fmt.Errorf("failed to encode lockfile: %s", err)
+	}
+
+	return os.WriteFile(lockPath, buf.Bytes(), 0644)
+}
+
+// Matches returns true if the lockfile was generated from the given [godot] settings and
+// records the editor package for the given platform.
+func (l *Lockfile) Matches(config BuildConfigGodot, targetOS TargetOS, arch TargetArch) bool {
+	if l.Godot.Version != config.Version ||
+		l.Godot.Release != config.Release ||
+		l.Godot.Mono != config.Mono {
+		return false
+	}
+
+	_, ok := l.Package(LockPackageEditor, targetOS, arch)
+	return ok
+}
+
+// Engine returns the resolved engine recorded in the lockfile.
+func (l *Lockfile) Engine() GodotEngine {
+	return GodotEngine{
+		Version: l.Godot.ResolvedVersion,
+		Release: l.Godot.ResolvedRelease,
+		Mono:    l.Godot.Mono,
+	}
+}
+
+// Package returns the locked editor package for a platform, or the export templates package
+// when kind is LockPackageTemplates.
+func (l *Lockfile) Package(kind string, targetOS TargetOS, arch TargetArch) (LockfilePackage, bool) {
+	if targetOS == TargetOSMacOS {
+		arch = TargetArchUniversal
+	}
+
+	for _, pkg := range l.Packages {
+		if pkg.Kind != kind {
+			continue
+		}
+		if kind == LockPackageTemplates || (pkg.OS == targetOS.String() && pkg.Arch == arch.String()) {
+			return pkg, true
+		}
+	}
+	return LockfilePackage{}, false
+}
+
+// GenerateLockfile records the packages of a resolved engine for every platform, using the
+// release's SHA512-SUMS.txt. When the checksums file isn't available, only the packages with a
+// checksum set in the config are recorded.
+func (d *Downloader) GenerateLockfile(config BuildConfigGodot, engine GodotEngine, targetOS TargetOS, arch TargetArch) (*Lockfile, error) {
+	lock := &Lockfile{
+		Godot: LockfileGodot{
+			Version:         config.Version,
+			Release:         config.Release,
+			Mono:            config.Mono,
+			ResolvedVersion: engine.Version,
+			ResolvedRelease: engine.Release,
+		},
+		Packages: make([]LockfilePackage, 0),
+	}
+
+	checksums, err := d.fetchChecksums(engine)
+	if err != nil {
+		if config.Checksum == "" {
+			return nil, err
+		}
+		d.logger.Warnf("Only locking the %s %s package: %s", targetOS, arch, err)
+		checksums = map[string]string{}
+	}
+
+	addPackage := func(kind string, pkgOS string, pkgArch string, fileName string, checksum string) error {
+		if checksum == "" {
+			return nil
+		}
+		fileURL, err := d.releaseURL(engine, fileName)
+		if err != nil {
+			return err
+		}
+		lock.Packages = append(lock.Packages, LockfilePackage{
+			Kind:   kind,
+			OS:     pkgOS,
+			Arch:   pkgArch,
+			File:   fileName,
+			URL:    fileURL,
+			SHA512: checksum,
+		})
+		return nil
+	}
+
+	for _, platform := range lockPlatforms {
+		for _, platformArch := range platform.archs {
+			fileName, err := getRemoteFileName(platform.os, platformArch, engine)
+			if err != nil {
+				continue
+			}
+
+			checksum := checksums[fileName]
+			if platform.os == targetOS && (platformArch == arch || targetOS == TargetOSMacOS) && config.Checksum != "" {
+				checksum = config.Checksum
+			}
+			if err := addPackage(LockPackageEditor, platform.os.String(), platformArch.String(), fileName, checksum); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	templatesFileName := getExportTemplatesFileName(engine)
+	templatesChecksum := checksums[templatesFileName]
+	if config.TemplatesChecksum != "" {
+		templatesChecksum = config.TemplatesChecksum
+	}
+	if err := addPackage(LockPackageTemplates, "", "", templatesFileName, templatesChecksum); err != nil {
+		return nil, err
+	}
+
+	return lock, nil
+}
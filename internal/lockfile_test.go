@@ -0,0 +1,39 @@
+package internal
+
+import "testing"
+
+func TestLockfileMatches(t *testing.T) {
+	lock := &Lockfile{
+		Godot: LockfileGodot{Version: "~4.2", Release: "stable", ResolvedVersion: "4.2.1", ResolvedRelease: "stable"},
+		Packages: []LockfilePackage{
+			{Kind: LockPackageEditor, OS: "linux", Arch: "x86_64", File: "Godot_v4.2.1-stable_linux.x86_64.zip"},
+			{Kind: LockPackageEditor, OS: "macos", Arch: "universal", File: "Godot_v4.2.1-stable_macos.universal.zip"},
+			{Kind: LockPackageTemplates, File: "Godot_v4.2.1-stable_export_templates.tpz"},
+		},
+	}
+	var config BuildConfigGodot = BuildConfigGodot{Version: "~4.2", Release: "stable"}
+
+	tests := []struct {
+		name    string
+		config  BuildConfigGodot
+		os      TargetOS
+		arch    TargetArch
+		matches bool
+	}{
+		{"locked platform", config, TargetOSLinux, TargetArchX86_64, true},
+		{"macos any arch", config, TargetOSMacOS, TargetArchARM64, true},
+		{"missing arch", config, TargetOSLinux, TargetArchARM64, false},
+		{"missing os", config, TargetOSWindows, TargetArchX86_64, false},
+		{"other version", BuildConfigGodot{Version: "4.3", Release: "stable"}, TargetOSLinux, TargetArchX86_64, false},
+		{"other release", BuildConfigGodot{Version: "~4.2", Release: "rc1"}, TargetOSLinux, TargetArchX86_64, false},
+		{"mono", BuildConfigGodot{Version: "~4.2", Release: "stable", Mono: true}, TargetOSLinux, TargetArchX86_64, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if matches := lock.Matches(test.config, test.os, test.arch); matches != test.matches {
+				t.Errorf("expected Matches to return %t, got %t", test.matches, matches)
+			}
+		})
+	}
+}
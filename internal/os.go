@@ -1,6 +1,9 @@
 package internal
 
-import "runtime"
+import (
+	"fmt"
+	"runtime"
+)
 
 type TargetOS uint8
 
@@ -22,6 +25,19 @@ func (t TargetOS) String() string {
 	return ""
 }
 
+// ParseTargetOS returns the target OS for the given name, accepting both gbt and Go names.
+func ParseTargetOS(name string) (TargetOS, error) {
+	switch name {
+	case "linux":
+		return TargetOSLinux, nil
+	case "windows":
+		return TargetOSWindows, nil
+	case "macos", "darwin":
+		return TargetOSMacOS, nil
+	}
+	return TargetOSLinux, fmt.Errorf("unknown OS %q", name)
+}
+
 func NewTargetOSFromRuntime(GOOSRuntime string) TargetOS {
 	switch GOOSRuntime {
 	case "linux":
@@ -0,0 +1,302 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	SourceTypeGitHub    = "github"
+	SourceTypeTuxFamily = "tuxfamily"
+	SourceTypeCustom    = "custom"
+)
+
+const defaultGitHubURL = "https://github.com/godotengine/godot-builds"
+const defaultTuxFamilyURL = "https://downloads.tuxfamily.org/godotengine/"
+const gitHubAPIURL = "https://api.github.com"
+const gitHubReleasePages = 5
+
+// DownloadSource is a location Godot packages can be downloaded from, each with its own URL layout.
+type DownloadSource interface {
+	// Name returns a name for the source to use in logs.
+	Name() string
+	// Headers returns the extra headers sent with requests to the source.
+	Headers() http.Header
+	// FileURL returns the URL of a file of the given engine release.
+	FileURL(engine GodotEngine, fileName string) (string, error)
+	// Index returns an index of the versions available from the source, or nil if the source can't list them.
+	Index(d *Downloader) VersionIndex
+}
+
+type DownloadSourceOptions struct {
+	// URL overrides the default location of the source, it is required for custom sources
+	URL  string
+	Name string
+	// Headers are sent with every request to the source, such as an Authorization header
+	Headers http.Header
+}
+
+// DefaultDownloadSources returns the sources used when none are configured, GitHub releases
+// followed by TuxFamily for older releases.
+func DefaultDownloadSources() []DownloadSource {
+	github, _ := NewDownloadSource(SourceTypeGitHub, &DownloadSourceOptions{})
+	tuxfamily, _ := NewDownloadSource(SourceTypeTuxFamily, &DownloadSourceOptions{})
+	return []DownloadSource{github, tuxfamily}
+}
+
+// NewDownloadSource creates a download source of the given type. The URL is optional for the
+// GitHub and TuxFamily layouts, and is a template with {version}, {release} and {file}
+// placeholders for custom sources.
+func NewDownloadSource(sourceType string, options *DownloadSourceOptions) (DownloadSource, error) {
+	var sourceURL string = options.URL
+	var base sourceBase = sourceBase{name: options.Name, headers: options.Headers}
+	if base.headers == nil {
+		base.headers = http.Header{}
+	}
+
+	switch sourceType {
+	case SourceTypeGitHub:
+		if sourceURL == "" {
+			sourceURL = defaultGitHubURL
+		}
+		if base.name == "" {
+			base.name = "GitHub"
+		}
+		return &gitHubSource{sourceBase: base, repositoryURL: strings.TrimSuffix(sourceURL, "/")}, nil
+	case SourceTypeTuxFamily:
+		if sourceURL == "" {
+			sourceURL = defaultTuxFamilyURL
+		}
+		if base.name == "" {
+			base.name = "TuxFamily"
+		}
+		return &tuxFamilySource{sourceBase: base, repositoryURL: sourceURL}, nil
+	case SourceTypeCustom:
+		if !strings.Contains(sourceURL, "{file}") {
+			return nil, fmt.Errorf("custom source URL must contain a {file} placeholder")
+		}
+		if base.name == "" {
+			base.name = sourceURL
+		}
+		return &customSource{sourceBase: base, template: sourceURL}, nil
+	}
+
+	return nil, fmt.Errorf("unknown source type %q, expected %s, %s or %s", sourceType, SourceTypeGitHub, SourceTypeTuxFamily, SourceTypeCustom)
+}
+
+// sourceBase holds what every download source has in common.
+type sourceBase struct {
+	name    string
+	headers http.Header
+}
+
+func (s *sourceBase) Name() string {
+	return s.name
+}
+
+func (s *sourceBase) Headers() http.Header {
+	return s.headers
+}
+
+// gitHubSource downloads from GitHub releases tagged by version and release, such as "4.2.1-stable".
+type gitHubSource struct {
+	sourceBase
+	repositoryURL string
+}
+
+func (s *gitHubSource) FileURL(engine GodotEngine, fileName string) (string, error) {
+	return fmt.Sprintf("%s/releases/download/%s-%s/%s", s.repositoryURL, engine.Version, engine.Release, url.PathEscape(fileName)), nil
+}
+
+func (s *gitHubSource) Index(d *Downloader) VersionIndex {
+	repoURL, err := url.Parse(s.repositoryURL)
+	if err != nil || repoURL.Host != "github.com" {
+		return nil
+	}
+	return &gitHubIndex{downloader: d, repository: strings.Trim(repoURL.Path, "/")}
+}
+
+// gitHubIndex reads versions from the tags of a repository's GitHub releases.
+type gitHubIndex struct {
+	downloader *Downloader
+	repository string
+
+	releases map[string][]string
+}
+
+// load lists the repository's releases, grouping the release names by version.
+func (i *gitHubIndex) load() error {
+	if i.releases != nil {
+		return nil
+	}
+
+	releases := map[string][]string{}
+	for page := 1; page <= gitHubReleasePages; page++ {
+		releasesURL := fmt.Sprintf("%s/repos/%s/releases?per_page=100&page=%d", gitHubAPIURL, i.repository, page)
+		i.downloader.logger.Debugf("Reading version index %s", releasesURL)
+
+		content, err := i.downloader.fetch(releasesURL, nil)
+		if err != nil {
+			return err
+		}
+
+		var tags []struct {
+			TagName string `json:"tag_name"`
+		}
+		if err := json.Unmarshal(content, &tags); err != nil {
+			return fmt.Errorf("failed to parse GitHub releases: %s", err)
+		}
+		if len(tags) == 0 {
+			break
+		}
+
+		for _, tag := range tags {
+			version, release, found := strings.Cut(tag.TagName, "-")
+			if found && indexVersionPattern.MatchString(version) {
+				releases[version] = append(releases[version], release)
+			}
+		}
+	}
+
+	i.releases = releases
+	return nil
+}
+
+func (i *gitHubIndex) Versions() ([]string, error) {
+	if err := i.load(); err != nil {
+		return nil, err
+	}
+
+	var versions []string = make([]string, 0, len(i.releases))
+	for version := range i.releases {
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+func (i *gitHubIndex) Releases(version string) ([]string, error) {
+	if err := i.load(); err != nil {
+		return nil, err
+	}
+	return i.releases[version], nil
+}
+
+// tuxFamilySource downloads from a directory tree laid out like downloads.tuxfamily.org, where
+// pre-releases and mono builds are kept in subdirectories of the version.
+type tuxFamilySource struct {
+	sourceBase
+	repositoryURL string
+}
+
+func (s *tuxFamilySource) FileURL(engine GodotEngine, fileName string) (string, error) {
+	downloadURL, err := url.Parse(s.repositoryURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse download repository URL: %s", err)
+	}
+
+	downloadURL.Path = path.Join(downloadURL.Path, engine.Version)
+	if engine.Release != "stable" {
+		downloadURL.Path = path.Join(downloadURL.Path, engine.Release)
+	}
+	if engine.Mono {
+		downloadURL.Path = path.Join(downloadURL.Path, "mono")
+	}
+
+	downloadURL.Path = path.Join(downloadURL.Path, fileName)
+	return downloadURL.String(), nil
+}
+
+// dirURL returns the URL of a directory within the repository.
+func (s *tuxFamilySource) dirURL(dir string) (string, error) {
+	repoURL, err := url.Parse(s.repositoryURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse download repository URL: %s", err)
+	}
+
+	// path.Join drops trailing slashes, which directory listings need
+	repoURL.Path = strings.TrimSuffix(repoURL.Path, "/") + "/"
+	if dir != "" {
+		repoURL.Path += dir + "/"
+	}
+	return repoURL.String(), nil
+}
+
+func (s *tuxFamilySource) Index(d *Downloader) VersionIndex {
+	return &directoryIndex{downloader: d, source: s}
+}
+
+// customSource downloads from a URL template with {version}, {release} and {file} placeholders.
+type customSource struct {
+	sourceBase
+	template string
+}
+
+func (s *customSource) FileURL(engine GodotEngine, fileName string) (string, error) {
+	replacer := strings.NewReplacer(
+		"{version}", engine.Version,
+		"{release}", engine.Release,
+		"{file}", url.PathEscape(fileName),
+	)
+	return replacer.Replace(s.template), nil
+}
+
+func (s *customSource) Index(d *Downloader) VersionIndex {
+	return nil
+}
+
+// sourcesIndex reads versions from the first download source that can list them.
+type sourcesIndex struct {
+	downloader *Downloader
+	index      VersionIndex
+}
+
+// VersionIndex returns an index of the versions available from the downloader's sources.
+// Sources are tried in order, and the first one that lists its versions is used.
+func (d *Downloader) VersionIndex() VersionIndex {
+	return &sourcesIndex{downloader: d}
+}
+
+func (i *sourcesIndex) Versions() ([]string, error) {
+	if i.index != nil {
+		return i.index.Versions()
+	}
+
+	// Offline, constraints can only be resolved to the versions vendored with `gbt fetch`
+	if i.downloader.offline {
+		i.index = &vendorIndex{dir: i.downloader.vendorDir}
+		return i.index.Versions()
+	}
+
+	var lastErr error = fmt.Errorf("none of the download sources can list versions")
+	for _, source := range i.downloader.sources {
+		index := source.Index(i.downloader)
+		if index == nil {
+			continue
+		}
+
+		versions, err := index.Versions()
+		if err != nil {
+			i.downloader.logger.Warnf("Failed to list versions from %s: %s", source.Name(), err)
+			lastErr = err
+			continue
+		}
+
+		i.index = index
+		return versions, nil
+	}
+
+	return nil, lastErr
+}
+
+func (i *sourcesIndex) Releases(version string) ([]string, error) {
+	if i.index == nil {
+		if _, err := i.Versions(); err != nil {
+			return nil, err
+		}
+	}
+	return i.index.Releases(version)
+}
@@ -0,0 +1,283 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultVendorDir is where `gbt fetch` stores the packages a project needs, relative to the project.
+const DefaultVendorDir = "vendor/godot"
+
+// Artifact is a package needed to set up Godot for a build.
+type Artifact struct {
+	Engine   GodotEngine
+	FileName string
+	// Checksum is the expected SHA-512 of the package, looked up in the release's
+	// SHA512-SUMS.txt when empty
+	Checksum string
+	// Custom is the location of a custom package, nil for packages from the download sources
+	Custom *PackageLocation
+	// Headers are sent when downloading a custom package
+	Headers http.Header
+}
+
+// BuildArtifacts returns the packages the config needs on the given target: the Godot package if
+// godot is true, and the export templates package if templates is true. Custom packages on the
+// local disk are left out, they never need downloading.
+func BuildArtifacts(config BuildConfigGodot, targetOS TargetOS, arch TargetArch, godot bool, templates bool) ([]Artifact, error) {
+	engine := config.Engine()
+	var artifacts []Artifact = make([]Artifact, 0, 2)
+
+	if godot {
+		godotArtifact, err := buildArtifact(config.EditorEngine(), config.Source, config.Checksum, config.SourceHTTPHeaders(), func() (string, error) {
+			return getRemoteFileName(targetOS, arch, engine)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if godotArtifact != nil {
+			artifacts = append(artifacts, *godotArtifact)
+		}
+	}
+
+	if templates {
+		templatesArtifact, err := buildArtifact(engine, config.TemplatesSource, config.TemplatesChecksum, config.SourceHTTPHeaders(), func() (string, error) {
+			return getExportTemplatesFileName(engine), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if templatesArtifact != nil {
+			artifacts = append(artifacts, *templatesArtifact)
+		}
+	}
+
+	return artifacts, nil
+}
+
+// buildArtifact returns the artifact for a package, named by fileName unless it comes from a custom
+// source, which is downloaded with the given headers.
+func buildArtifact(engine GodotEngine, source string, checksum string, headers http.Header, fileName func() (string, error)) (*Artifact, error) {
+	if source == "" {
+		name, err := fileName()
+		if err != nil {
+			return nil, err
+		}
+		return &Artifact{Engine: engine, FileName: name, Checksum: checksum}, nil
+	}
+
+	location, err := ParsePackageLocation(source)
+	if err != nil {
+		return nil, err
+	}
+	if location.Kind == PackageLocationPath {
+		return nil, nil
+	}
+
+	packageURL, err := url.Parse(location.Location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse package URL: %s", err)
+	}
+	return &Artifact{Engine: engine, FileName: path.Base(packageURL.Path), Checksum: checksum, Custom: &location, Headers: headers}, nil
+}
+
+// vendorPath returns the path of a file of the given engine in the vendor directory.
+func (d *Downloader) vendorPath(engine GodotEngine, fileName string) string {
+	return filepath.Join(d.vendorDir, engine.String(), fileName)
+}
+
+// lookupVendor returns the path of the vendored file for the given key, if it is present and matches its checksum.
+func (d *Downloader) lookupVendor(key CacheKey) (string, bool) {
+	vendorPath := d.vendorPath(key.Engine, key.FileName)
+	if _, err := os.Stat(vendorPath); err != nil {
+		return "", false
+	}
+
+	actual, err := FileSHA512(vendorPath)
+	if err != nil || verifyChecksum(key.FileName, key.Checksum, actual) != nil {
+		d.logger.Warnf("Ignoring vendored %s, it doesn't match the expected checksum", vendorPath)
+		return "", false
+	}
+
+	return vendorPath, true
+}
+
+// vendorIndex lists the Godot releases vendored with `gbt fetch`, to resolve versions offline.
+type vendorIndex struct {
+	dir string
+}
+
+// engines returns every release with a directory in the vendor directory.
+func (i *vendorIndex) engines() ([]GodotEngine, error) {
+	dirs, err := os.ReadDir(i.dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read vendor directory: %s", err)
+	}
+
+	var engines []GodotEngine = make([]GodotEngine, 0, len(dirs))
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		engine, err := ParseEngineName(dir.Name())
+		if err != nil || engine.Custom {
+			continue
+		}
+		engines = append(engines, engine)
+	}
+	return engines, nil
+}
+
+func (i *vendorIndex) Versions() ([]string, error) {
+	engines, err := i.engines()
+	if err != nil {
+		return nil, err
+	}
+	if len(engines) == 0 {
+		return nil, fmt.Errorf("no Godot versions are vendored in %s, run `gbt fetch` on a connected machine or commit %s", i.dir, LockFile)
+	}
+
+	var versions []string = make([]string, 0, len(engines))
+	for _, engine := range engines {
+		if !containsString(versions, engine.Version) {
+			versions = append(versions, engine.Version)
+		}
+	}
+	return versions, nil
+}
+
+func (i *vendorIndex) Releases(version string) ([]string, error) {
+	engines, err := i.engines()
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []string = make([]string, 0)
+	for _, engine := range engines {
+		if engine.Version == version && !containsString(releases, engine.Release) {
+			releases = append(releases, engine.Release)
+		}
+	}
+	return releases, nil
+}
+
+// fetchArtifact returns the local path of an artifact, from the vendor directory, the cache or
+// the download sources.
+func (d *Downloader) fetchArtifact(targetOS TargetOS, artifact Artifact) (string, error) {
+	if artifact.Custom != nil {
+		return d.FetchCustomPackage(targetOS, artifact.Engine, *artifact.Custom, artifact.Checksum, artifact.Headers)
+	}
+	return d.downloadReleaseFile(targetOS, artifact.Engine, artifact.FileName, artifact.Checksum)
+}
+
+// MissingArtifacts returns the artifacts that are neither vendored nor cached, so can't be set
+// up without network access.
+func (d *Downloader) MissingArtifacts(targetOS TargetOS, artifacts []Artifact) []Artifact {
+	var missing []Artifact = make([]Artifact, 0)
+	for _, artifact := range artifacts {
+		checksum := artifact.Checksum
+		if checksum == "" {
+			var err error
+			if checksum, err = d.fetchChecksum(artifact.Engine, artifact.FileName); err != nil {
+				missing = append(missing, artifact)
+				continue
+			}
+		}
+
+		key := CacheKey{
+			Engine:   artifact.Engine,
+			OS:       targetOS,
+			Checksum: checksum,
+			FileName: artifact.FileName,
+		}
+		if _, ok := d.lookupVendor(key); ok {
+			continue
+		}
+		if _, ok := d.cache.Lookup(key); ok {
+			continue
+		}
+		missing = append(missing, artifact)
+	}
+	return missing
+}
+
+// VendorArtifact downloads an artifact and copies it into the vendor directory, along with the
+// release's checksums so the vendored packages can be verified offline. It returns the vendored path.
+func (d *Downloader) VendorArtifact(targetOS TargetOS, artifact Artifact) (string, error) {
+	packagePath, err := d.fetchArtifact(targetOS, artifact)
+	if err != nil {
+		return "", err
+	}
+
+	vendorPath := d.vendorPath(artifact.Engine, artifact.FileName)
+	if err := os.MkdirAll(filepath.Dir(vendorPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create vendor directory: %s", err)
+	}
+	if packagePath != vendorPath {
+		if err := copyFile(packagePath, vendorPath); err != nil {
+			return "", fmt.Errorf("failed to vendor %s: %s", artifact.FileName, err)
+		}
+	}
+
+	if artifact.Custom == nil {
+		checksums, err := d.fetchChecksums(artifact.Engine)
+		if err != nil {
+			d.logger.Warnf("Not vendoring %s for %s: %s", checksumsFileName, artifact.Engine, err)
+			return vendorPath, nil
+		}
+		if err := writeChecksums(d.vendorPath(artifact.Engine, checksumsFileName), checksums); err != nil {
+			return "", fmt.Errorf("failed to vendor %s: %s", checksumsFileName, err)
+		}
+	}
+
+	return vendorPath, nil
+}
+
+// writeChecksums writes checksums in the sha512sum format read by ParseChecksums.
+func writeChecksums(filePath string, checksums map[string]string) error {
+	var names []string = make([]string, 0, len(checksums))
+	for name := range checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", checksums[name], name)
+	}
+	return os.WriteFile(filePath, []byte(b.String()), 0644)
+}
+
+// copyFile copies a file, replacing the destination through a rename so it is never left half written.
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	partialPath := dst + cachePartialSuffix
+	out, err := os.Create(partialPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		_ = os.Remove(partialPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(partialPath)
+		return err
+	}
+
+	return os.Rename(partialPath, dst)
+}
@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newReleaseServer serves testPackage as every file of a release, along with its SHA512-SUMS.txt.
+func newReleaseServer(t *testing.T, fileNames ...string) *httptest.Server {
+	t.Helper()
+
+	var sums strings.Builder
+	for _, fileName := range fileNames {
+		fmt.Fprintf(&sums, "%s  %s\n", testPackageChecksum(), fileName)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch name := path.Base(r.URL.Path); {
+		case name == checksumsFileName:
+			_, _ = w.Write([]byte(sums.String()))
+		case containsString(fileNames, name):
+			_, _ = w.Write(testPackage)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// newOfflineDownloader creates an offline downloader reading the vendor directory of another
+// downloader, with an empty cache.
+func newOfflineDownloader(t *testing.T, vendorDir string, sources ...DownloadSource) *Downloader {
+	t.Helper()
+
+	dir := t.TempDir()
+	return NewDownloader(TargetOSLinux, &recordingLogger{}, &DownloaderOptions{
+		Sources:    sources,
+		BinDir:     filepath.Join(dir, "bin"),
+		EnginesDir: filepath.Join(dir, "engines"),
+		CacheDir:   filepath.Join(dir, "cache"),
+		VendorDir:  vendorDir,
+		Retries:    -1,
+		Offline:    true,
+	})
+}
+
+func TestFetchThenOfflineInstall(t *testing.T) {
+	var config BuildConfigGodot = BuildConfigGodot{Version: "4.3", Release: "stable"}
+	engine := config.Engine()
+	godotFile, err := getRemoteFileName(TargetOSLinux, TargetArchX86_64, engine)
+	if err != nil {
+		t.Fatalf("failed to get package name: %s", err)
+	}
+
+	server := newReleaseServer(t, godotFile, getExportTemplatesFileName(engine))
+	source := newTestSource(t, "mirror", server)
+	online := newTestDownloader(t, source)
+
+	artifacts, err := BuildArtifacts(config, TargetOSLinux, TargetArchX86_64, true, true)
+	if err != nil {
+		t.Fatalf("failed to list artifacts: %s", err)
+	}
+	for _, artifact := range artifacts {
+		if _, err := online.VendorArtifact(TargetOSLinux, artifact); err != nil {
+			t.Fatalf("failed to vendor %s: %s", artifact.FileName, err)
+		}
+	}
+	server.Close()
+
+	offline := newOfflineDownloader(t, online.vendorDir, source)
+	if missing := offline.MissingArtifacts(TargetOSLinux, artifacts); len(missing) != 0 {
+		t.Errorf("expected every package to be vendored, missing %v", missing)
+	}
+
+	resolved, err := ResolveEngine(offline.VersionIndex(), GodotEngine{Version: "~4.3", Release: "stable"})
+	if err != nil {
+		t.Fatalf("failed to resolve a constraint against the vendored versions: %s", err)
+	}
+	if resolved.String() != "4.3-stable" {
+		t.Errorf("expected 4.3-stable, got %s", resolved)
+	}
+
+	godotPackage, err := offline.DownloadGodot(TargetOSLinux, TargetArchX86_64, engine, "")
+	if err != nil {
+		t.Fatalf("failed to install offline: %s", err)
+	}
+	if expected := offline.vendorPath(engine, godotFile); godotPackage != expected {
+		t.Errorf("expected the vendored package %s, got %s", expected, godotPackage)
+	}
+	assertTestPackage(t, godotPackage)
+}
+
+func TestMissingArtifacts(t *testing.T) {
+	var config BuildConfigGodot = BuildConfigGodot{Version: "4.3", Release: "stable"}
+	engine := config.Engine()
+	godotFile, _ := getRemoteFileName(TargetOSLinux, TargetArchX86_64, engine)
+	templatesFile := getExportTemplatesFileName(engine)
+
+	// Only the Godot package is vendored
+	server := newReleaseServer(t, godotFile, templatesFile)
+	defer server.Close()
+	online := newTestDownloader(t, newTestSource(t, "mirror", server))
+	artifacts, _ := BuildArtifacts(config, TargetOSLinux, TargetArchX86_64, true, true)
+	if _, err := online.VendorArtifact(TargetOSLinux, artifacts[0]); err != nil {
+		t.Fatalf("failed to vendor %s: %s", artifacts[0].FileName, err)
+	}
+
+	offline := newOfflineDownloader(t, online.vendorDir)
+	missing := offline.MissingArtifacts(TargetOSLinux, artifacts)
+	if len(missing) != 1 || missing[0].FileName != templatesFile {
+		t.Errorf("expected only %s to be missing, got %v", templatesFile, missing)
+	}
+
+	// Without the vendored checksums, a package can't be verified offline
+	other := BuildConfigGodot{Version: "4.2.1", Release: "stable"}
+	otherArtifacts, _ := BuildArtifacts(other, TargetOSLinux, TargetArchX86_64, true, false)
+	if missing := offline.MissingArtifacts(TargetOSLinux, otherArtifacts); len(missing) != 1 {
+		t.Errorf("expected the unvendored release to be missing, got %v", missing)
+	}
+
+	_, err := ResolveEngine(offline.VersionIndex(), GodotEngine{Version: "4.x", Release: "latest-rc"})
+	var versionErr *UnresolvableVersionError
+	if !errors.As(err, &versionErr) {
+		t.Errorf("expected no vendored release to match, got %v", err)
+	}
+}
+
+func TestOfflineVersionIndexWithoutVendoredVersions(t *testing.T) {
+	offline := newOfflineDownloader(t, filepath.Join(t.TempDir(), "vendor"))
+
+	_, err := ResolveEngine(offline.VersionIndex(), GodotEngine{Version: "~4.3", Release: "stable"})
+	if err == nil || !strings.Contains(err.Error(), "gbt fetch") {
+		t.Errorf("expected an error pointing at gbt fetch, got %v", err)
+	}
+}
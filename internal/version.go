@@ -0,0 +1,229 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LatestVersion is the version constraint that matches every Godot version.
+const LatestVersion = "latest"
+
+// latestReleasePrefix marks a release such as "latest-rc", which selects the newest release of a channel.
+const latestReleasePrefix = "latest-"
+
+var exactVersionPattern = regexp.MustCompile(`^=?\d+\.\d+(\.\d+)?$`)
+var releaseChannelPattern = regexp.MustCompile(`^([a-z]+)(\d*)$`)
+
+// GodotVersion is a parsed Godot version number.
+type GodotVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseGodotVersion parses a version such as "4.2" or "4.2.1".
+func ParseGodotVersion(version string) (GodotVersion, error) {
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return GodotVersion{}, fmt.Errorf("invalid Godot version %q", version)
+	}
+
+	var numbers [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return GodotVersion{}, fmt.Errorf("invalid Godot version %q", version)
+		}
+		numbers[i] = n
+	}
+
+	return GodotVersion{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+// Compare returns -1, 0 or 1 if the version is less than, equal to or greater than the other version.
+func (v GodotVersion) Compare(other GodotVersion) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if diff < 0 {
+			return -1
+		}
+		if diff > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// IsExactVersion returns true if the version names a single Godot version rather than a constraint.
+// Godot names its x.y.0 releases with two parts, so "4.3" and "=4.3" are exact versions.
+func IsExactVersion(version string) bool {
+	_, ok := ExactVersion(version)
+	return ok
+}
+
+// ExactVersion returns the Godot version named by an exact version such as "4.2.1" or "=4.3",
+// or false if the version is a constraint.
+func ExactVersion(version string) (string, bool) {
+	version = strings.TrimSpace(version)
+	if !exactVersionPattern.MatchString(version) {
+		return "", false
+	}
+	return strings.TrimPrefix(version, "="), true
+}
+
+// IsLatestRelease returns true if the release selects the newest release of a channel, such as "latest-rc".
+func IsLatestRelease(release string) bool {
+	return strings.HasPrefix(release, latestReleasePrefix)
+}
+
+// versionComparison is a single comparison of a version constraint, such as ">=4.1".
+type versionComparison struct {
+	op      string
+	version GodotVersion
+}
+
+func (c versionComparison) matches(version GodotVersion) bool {
+	cmp := version.Compare(c.version)
+	switch c.op {
+	case ">=":
+		return cmp >= 0
+	case ">":
+		return cmp > 0
+	case "<=":
+		return cmp <= 0
+	case "<":
+		return cmp < 0
+	}
+	return cmp == 0
+}
+
+// VersionConstraint selects a range of Godot versions.
+type VersionConstraint struct {
+	comparisons []versionComparison
+}
+
+// ParseVersionConstraint parses a version constraint. Supported forms are exact versions ("4.2.1",
+// "4.3"), partial versions matching every patch release ("~4.2", "4.2.x", "4.x"), space-separated
+// comparisons (">=4.1 <4.3") and "latest".
+func ParseVersionConstraint(constraint string) (VersionConstraint, error) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == LatestVersion || constraint == "*" || constraint == "" {
+		return VersionConstraint{}, nil
+	}
+
+	var comparisons []versionComparison = make([]versionComparison, 0)
+	for _, field := range strings.Fields(constraint) {
+		parsed, err := parseVersionComparisons(field)
+		if err != nil {
+			return VersionConstraint{}, fmt.Errorf("invalid version constraint %q: %s", constraint, err)
+		}
+		comparisons = append(comparisons, parsed...)
+	}
+
+	return VersionConstraint{comparisons: comparisons}, nil
+}
+
+// parseVersionComparisons parses a single term of a version constraint.
+func parseVersionComparisons(term string) ([]versionComparison, error) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if version, found := strings.CutPrefix(term, op); found {
+			v, err := ParseGodotVersion(version)
+			if err != nil {
+				return nil, err
+			}
+			return []versionComparison{{op: op, version: v}}, nil
+		}
+	}
+
+	term, tilde := strings.CutPrefix(term, "~")
+	trimmed := strings.TrimSuffix(strings.TrimSuffix(term, ".x"), ".*")
+	wildcard := trimmed != term
+	term = trimmed
+
+	parts := strings.Split(term, ".")
+	switch len(parts) {
+	case 1:
+		// "4.x" matches every 4.* version
+		major, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", term)
+		}
+		return []versionComparison{
+			{op: ">=", version: GodotVersion{Major: major}},
+			{op: "<", version: GodotVersion{Major: major + 1}},
+		}, nil
+	case 2, 3:
+		v, err := ParseGodotVersion(term)
+		if err != nil {
+			return nil, err
+		}
+		if !tilde && !wildcard {
+			return []versionComparison{{op: "=", version: v}}, nil
+		}
+		// "~4.2", "4.2.x" and "~4.2.1" match later patch releases of the same minor version
+		return []versionComparison{
+			{op: ">=", version: v},
+			{op: "<", version: GodotVersion{Major: v.Major, Minor: v.Minor + 1}},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("invalid version %q", term)
+}
+
+// Matches returns true if the version satisfies the constraint.
+func (c VersionConstraint) Matches(version GodotVersion) bool {
+	for _, comparison := range c.comparisons {
+		if !comparison.matches(version) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortVersionsDescending sorts version strings from newest to oldest, dropping any that can't be parsed.
+func sortVersionsDescending(versions []string) []string {
+	type parsedVersion struct {
+		name    string
+		version GodotVersion
+	}
+
+	var parsed []parsedVersion = make([]parsedVersion, 0, len(versions))
+	for _, name := range versions {
+		if v, err := ParseGodotVersion(name); err == nil {
+			parsed = append(parsed, parsedVersion{name: name, version: v})
+		}
+	}
+
+	sort.SliceStable(parsed, func(i, j int) bool {
+		return parsed[i].version.Compare(parsed[j].version) > 0
+	})
+
+	var sorted []string = make([]string, 0, len(parsed))
+	for _, p := range parsed {
+		sorted = append(sorted, p.name)
+	}
+	return sorted
+}
+
+// latestChannelRelease returns the newest release of a channel, such as "rc2" for the "rc" channel.
+func latestChannelRelease(releases []string, channel string) (string, bool) {
+	var best string
+	var bestNumber int = -1
+
+	for _, release := range releases {
+		match := releaseChannelPattern.FindStringSubmatch(release)
+		if match == nil || match[1] != channel {
+			continue
+		}
+
+		n, _ := strconv.Atoi(match[2])
+		if n > bestNumber {
+			best = release
+			bestNumber = n
+		}
+	}
+
+	return best, bestNumber >= 0
+}
@@ -0,0 +1,225 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var indexLinkPattern = regexp.MustCompile(`href="([^"?#]+)"`)
+var indexVersionPattern = regexp.MustCompile(`^\d+\.\d+(\.\d+)?$`)
+
+// VersionIndex lists the Godot versions and releases available for download.
+type VersionIndex interface {
+	// Versions returns every available version, such as "4.2" and "4.2.1".
+	Versions() ([]string, error)
+	// Releases returns the releases of a version, such as "stable" and "rc1".
+	Releases(version string) ([]string, error)
+}
+
+// directoryIndex reads versions from the directory listing of a download repository.
+type directoryIndex struct {
+	downloader *Downloader
+	source     *tuxFamilySource
+}
+
+// links returns the names of the files and directories linked from a directory listing.
+// Directory names keep their trailing slash.
+func (i *directoryIndex) links(dir string) ([]string, error) {
+	listingURL, err := i.source.dirURL(dir)
+	if err != nil {
+		return nil, err
+	}
+	i.downloader.logger.Debugf("Reading version index %s", listingURL)
+
+	content, err := i.downloader.fetch(listingURL, i.source.Headers())
+	if err != nil {
+		return nil, err
+	}
+
+	var links []string = make([]string, 0)
+	for _, match := range indexLinkPattern.FindAllStringSubmatch(string(content), -1) {
+		link := match[1]
+		// Listings may link with absolute paths, only the last path element matters
+		name := strings.TrimSuffix(link, "/")
+		if index := strings.LastIndex(name, "/"); index != -1 {
+			name = name[index+1:]
+		}
+		if name == "" || name == ".." {
+			continue
+		}
+		if strings.HasSuffix(link, "/") {
+			name += "/"
+		}
+		links = append(links, name)
+	}
+
+	return links, nil
+}
+
+func (i *directoryIndex) Versions() ([]string, error) {
+	links, err := i.links("")
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string = make([]string, 0)
+	for _, link := range links {
+		name, isDir := strings.CutSuffix(link, "/")
+		if isDir && indexVersionPattern.MatchString(name) {
+			versions = append(versions, name)
+		}
+	}
+	return versions, nil
+}
+
+func (i *directoryIndex) Releases(version string) ([]string, error) {
+	links, err := i.links(version)
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []string = make([]string, 0)
+	for _, link := range links {
+		name, isDir := strings.CutSuffix(link, "/")
+		if isDir && name != "mono" && releaseChannelPattern.MatchString(name) {
+			releases = append(releases, name)
+		}
+		// Pre-release versions already have a directory, so stable needs its packages to be present
+		if !isDir && strings.HasPrefix(name, fmt.Sprintf("Godot_v%s-stable", version)) && !containsString(releases, "stable") {
+			releases = append(releases, "stable")
+		}
+	}
+	return releases, nil
+}
+
+// VersionManifest is a local list of Godot versions, used to resolve versions offline.
+type VersionManifest struct {
+	Entries []VersionManifestEntry `json:"versions"`
+}
+
+// VersionManifestEntry lists the releases of a single version.
+type VersionManifestEntry struct {
+	Version  string   `json:"version"`
+	Releases []string `json:"releases"`
+}
+
+// LoadVersionManifest loads a JSON version manifest such as
+// {"versions": [{"version": "4.2.1", "releases": ["stable", "rc1"]}]}.
+func LoadVersionManifest(manifestPath string) (*VersionManifest, error) {
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version manifest: %s", err)
+	}
+
+	manifest := &VersionManifest{}
+	if err := json.Unmarshal(content, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse version manifest: %s", err)
+	}
+	return manifest, nil
+}
+
+func (m *VersionManifest) Versions() ([]string, error) {
+	var versions []string = make([]string, 0, len(m.Entries))
+	for _, entry := range m.Entries {
+		versions = append(versions, entry.Version)
+	}
+	return versions, nil
+}
+
+func (m *VersionManifest) Releases(version string) ([]string, error) {
+	for _, entry := range m.Entries {
+		if entry.Version == version {
+			return entry.Releases, nil
+		}
+	}
+	return []string{}, nil
+}
+
+// NeedsVersionResolution returns true if the engine's version or release is a constraint
+// that has to be resolved against a version index.
+func NeedsVersionResolution(engine GodotEngine) bool {
+	return !IsExactVersion(engine.Version) || IsLatestRelease(engine.Release)
+}
+
+// ResolveEngine resolves the version constraint and release of an engine to the newest matching
+// version and release in the index.
+func ResolveEngine(index VersionIndex, engine GodotEngine) (GodotEngine, error) {
+	constraint, err := ParseVersionConstraint(engine.Version)
+	if err != nil {
+		return engine, &UnresolvableVersionError{Version: engine.Version, Release: engine.Release, Err: err}
+	}
+
+	exact, isExact := ExactVersion(engine.Version)
+	var candidates []string = []string{exact}
+	if !isExact {
+		versions, err := index.Versions()
+		if err != nil {
+			return engine, fmt.Errorf("failed to list Godot versions: %w", err)
+		}
+
+		candidates = make([]string, 0)
+		for _, version := range sortVersionsDescending(versions) {
+			v, _ := ParseGodotVersion(version)
+			if constraint.Matches(v) {
+				candidates = append(candidates, version)
+			}
+		}
+	}
+
+	for _, version := range candidates {
+		releases, err := index.Releases(version)
+		if err != nil {
+			return engine, fmt.Errorf("failed to list releases of Godot %s: %w", version, err)
+		}
+
+		resolved := engine
+		resolved.Version = version
+		if channel, found := strings.CutPrefix(engine.Release, latestReleasePrefix); found {
+			release, ok := latestChannelRelease(releases, channel)
+			if !ok {
+				continue
+			}
+			resolved.Release = release
+			return resolved, nil
+		}
+
+		if containsString(releases, engine.Release) {
+			return resolved, nil
+		}
+	}
+
+	return engine, &UnresolvableVersionError{Version: engine.Version, Release: engine.Release}
+}
+
+// UnresolvableVersionError is returned when a version and release can't be resolved, because
+// the version constraint is invalid or no release matches it.
+type UnresolvableVersionError struct {
+	Version string
+	Release string
+	// Err is why the constraint is invalid, nil when no release matches it
+	Err error
+}
+
+func (e *UnresolvableVersionError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("no Godot release matches version %q and release %q", e.Version, e.Release)
+}
+
+func (e *UnresolvableVersionError) Unwrap() error {
+	return e.Err
+}
+
+// containsString returns true if the slice contains the value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
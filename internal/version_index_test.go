@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+)
+
+// failingIndex is a version index whose listings can't be fetched.
+type failingIndex struct {
+	err error
+}
+
+func (i failingIndex) Versions() ([]string, error) {
+	return nil, i.err
+}
+
+func (i failingIndex) Releases(version string) ([]string, error) {
+	return nil, i.err
+}
+
+func TestIsExactVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		exact   string
+		isExact bool
+	}{
+		{"4.2.1", "4.2.1", true},
+		{"4.3", "4.3", true},
+		{"4.0", "4.0", true},
+		{"=4.3", "4.3", true},
+		{"=4.2.1", "4.2.1", true},
+		{"~4.2", "", false},
+		{"4.2.x", "", false},
+		{"4.x", "", false},
+		{">=4.1 <4.3", "", false},
+		{"latest", "", false},
+		{"4", "", false},
+	}
+
+	for _, test := range tests {
+		exact, ok := ExactVersion(test.version)
+		if ok != test.isExact || exact != test.exact {
+			t.Errorf("%s: expected %q %t, got %q %t", test.version, test.exact, test.isExact, exact, ok)
+		}
+		if IsExactVersion(test.version) != test.isExact {
+			t.Errorf("%s: expected IsExactVersion to be %t", test.version, test.isExact)
+		}
+	}
+}
+
+func TestResolveEngine(t *testing.T) {
+	manifest := &VersionManifest{Entries: []VersionManifestEntry{
+		{Version: "4.1.3", Releases: []string{"stable"}},
+		{Version: "4.2", Releases: []string{"stable"}},
+		{Version: "4.2.1", Releases: []string{"rc1", "stable"}},
+		{Version: "4.3", Releases: []string{"rc1", "stable"}},
+		{Version: "4.3.1", Releases: []string{"stable"}},
+		{Version: "4.4", Releases: []string{"beta2"}},
+	}}
+
+	tests := []struct {
+		version  string
+		release  string
+		expected string
+	}{
+		{"4.3", "stable", "4.3-stable"},
+		{"=4.3", "stable", "4.3-stable"},
+		{"4.3", "latest-rc", "4.3-rc1"},
+		{"4.2.1", "latest-rc", "4.2.1-rc1"},
+		{"~4.2", "stable", "4.2.1-stable"},
+		{"4.2.x", "stable", "4.2.1-stable"},
+		{"~4.3", "stable", "4.3.1-stable"},
+		{"4.x", "stable", "4.3.1-stable"},
+		{"latest", "stable", "4.3.1-stable"},
+		{"latest", "latest-beta", "4.4-beta2"},
+		{">=4.1 <4.2", "stable", "4.1.3-stable"},
+	}
+
+	for _, test := range tests {
+		resolved, err := ResolveEngine(manifest, GodotEngine{Version: test.version, Release: test.release})
+		if err != nil {
+			t.Errorf("%s %s: unexpected error: %s", test.version, test.release, err)
+			continue
+		}
+		if resolved.String() != test.expected {
+			t.Errorf("%s %s: expected %s, got %s", test.version, test.release, test.expected, resolved)
+		}
+	}
+}
+
+func TestNeedsVersionResolution(t *testing.T) {
+	tests := []struct {
+		engine   GodotEngine
+		expected bool
+	}{
+		{GodotEngine{Version: "4.3", Release: "stable"}, false},
+		{GodotEngine{Version: "4.2.1", Release: "stable"}, false},
+		{GodotEngine{Version: "4.3", Release: "latest-rc"}, true},
+		{GodotEngine{Version: "~4.3", Release: "stable"}, true},
+		{GodotEngine{Version: "latest", Release: "stable"}, true},
+	}
+
+	for _, test := range tests {
+		if got := NeedsVersionResolution(test.engine); got != test.expected {
+			t.Errorf("%s-%s: expected %t, got %t", test.engine.Version, test.engine.Release, test.expected, got)
+		}
+	}
+}
+
+func TestResolveEngineUnresolvable(t *testing.T) {
+	manifest := &VersionManifest{Entries: []VersionManifestEntry{
+		{Version: "4.2.1", Releases: []string{"stable"}},
+	}}
+
+	for _, version := range []string{"3.x", "4.2.2", ">=4.2 <4.2", "four"} {
+		_, err := ResolveEngine(manifest, GodotEngine{Version: version, Release: "stable"})
+		var versionErr *UnresolvableVersionError
+		if !errors.As(err, &versionErr) {
+			t.Errorf("%s: expected an unresolvable version error, got %v", version, err)
+		}
+	}
+}
+
+func TestResolveEngineListingFails(t *testing.T) {
+	index := failingIndex{err: &NetworkError{errors.New("connection refused")}}
+
+	_, err := ResolveEngine(index, GodotEngine{Version: "4.x", Release: "stable"})
+	var networkErr *NetworkError
+	if !errors.As(err, &networkErr) {
+		t.Errorf("expected the listing's network error, got %v", err)
+	}
+	var versionErr *UnresolvableVersionError
+	if errors.As(err, &versionErr) {
+		t.Errorf("expected a failed listing not to be reported as an unresolvable version, got %s", err)
+	}
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// workspaceEnvVars hold the root of the checked out repository on each CI system.
+var workspaceEnvVars []string = []string{"GITHUB_WORKSPACE", "CI_PROJECT_DIR", "BUILD_SOURCESDIRECTORY"}
+
+// NormalizeAnnotationPath maps a file path to one relative to the root of the workspace, so
+// annotations point at the file in the repository. Godot's res:// paths and relative paths
+// are resolved against the working directory, which is the project's root.
+func NormalizeAnnotationPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	var absPath string = path
+	if resPath, ok := strings.CutPrefix(path, "res://"); ok {
+		absPath = filepath.Join(wd, filepath.FromSlash(resPath))
+	} else if !filepath.IsAbs(path) {
+		absPath = filepath.Join(wd, path)
+	}
+
+	var root string = wd
+	for _, name := range workspaceEnvVars {
+		if dir := os.Getenv(name); dir != "" {
+			root = dir
+			break
+		}
+	}
+
+	relPath, err := filepath.Rel(root, absPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		// Files outside of the workspace can't be annotated, keep them recognizable
+		return filepath.ToSlash(absPath)
+	}
+	return filepath.ToSlash(relPath)
+}
+
+// normalizeInput returns a copy of the input with its file path normalized for annotations.
+func normalizeInput(input NoticeMessageInput) NoticeMessageInput {
+	if input.Filename != nil {
+		filename := NormalizeAnnotationPath(*input.Filename)
+		input.Filename = &filename
+	}
+	return input
+}
@@ -0,0 +1,162 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// AzurePipelinesLogger is a logger that logs to Azure Pipelines using logging commands.
+type AzurePipelinesLogger struct {
+	info  *log.Logger
+	err   *log.Logger
+	debug *log.Logger
+}
+
+// NewAzurePipelinesLogger creates a new AzurePipelinesLogger.
+func NewAzurePipelinesLogger(debug bool) Logger {
+	var debugLogger *log.Logger
+	if debug {
+		debugLogger = log.New(os.Stdout, "##[debug]", 0)
+	}
+
+	return &AzurePipelinesLogger{
+		info:  log.New(os.Stdout, "", 0),
+		err:   log.New(os.Stderr, "", 0),
+		debug: debugLogger,
+	}
+}
+
+// azureEscapeData escapes a value so it can't end or break a logging command.
+func azureEscapeData(value string) string {
+	value = strings.ReplaceAll(value, "%", "%AZP25")
+	value = strings.ReplaceAll(value, "\r", "%0D")
+	return strings.ReplaceAll(value, "\n", "%0A")
+}
+
+// azureEscapeProperty escapes a logging command property value.
+func azureEscapeProperty(value string) string {
+	value = azureEscapeData(value)
+	value = strings.ReplaceAll(value, ";", "%3B")
+	return strings.ReplaceAll(value, "]", "%5D")
+}
+
+// Infof logs an info message.
+func (l *AzurePipelinesLogger) Infof(format string, args ...interface{}) {
+	l.info.Printf(format, args...)
+}
+
+// Warnf logs a warning message.
+func (l *AzurePipelinesLogger) Warnf(format string, args ...interface{}) {
+	l.info.Print(logIssue("warning", fmt.Sprintf(format, args...), NoticeMessageInput{}))
+}
+
+// Errorf logs an error message.
+func (l *AzurePipelinesLogger) Errorf(format string, args ...interface{}) {
+	l.err.Print(logIssue("error", fmt.Sprintf(format, args...), NoticeMessageInput{}))
+}
+
+// Debugf logs a debug message if debug logging is enabled.
+func (l *AzurePipelinesLogger) Debugf(format string, args ...interface{}) {
+	if l.debug != nil {
+		l.debug.Printf(format, args...)
+	}
+}
+
+// NoticeMessage logs a notice about a line. Azure Pipelines only annotates warnings and
+// errors, so the notice is logged with its location instead.
+func (l *AzurePipelinesLogger) NoticeMessage(message string, input NoticeMessageInput) {
+	input = normalizeInput(input)
+
+	var location string
+	if input.Filename != nil {
+		location = *input.Filename
+		if input.Line != nil {
+			location += fmt.Sprint(":", *input.Line)
+		}
+		location += ": "
+	}
+	if input.Title != nil {
+		message = *input.Title + ": " + message
+	}
+
+	l.info.Printf("##[section]%s%s", location, message)
+}
+
+// WarningAnnotation logs a warning about a line.
+func (l *AzurePipelinesLogger) WarningAnnotation(message string, input NoticeMessageInput) {
+	l.info.Print(logIssue("warning", message, input))
+}
+
+// ErrorAnnotation logs an error about a line.
+func (l *AzurePipelinesLogger) ErrorAnnotation(message string, input NoticeMessageInput) {
+	l.err.Print(logIssue("error", message, input))
+}
+
+// logIssue formats a task.logissue command pointing at the input's location.
+func logIssue(issueType string, message string, input NoticeMessageInput) string {
+	input = normalizeInput(input)
+
+	var properties string = "type=" + issueType
+	if input.Filename != nil {
+		properties += ";sourcepath=" + azureEscapeProperty(*input.Filename)
+	}
+	if input.Line != nil {
+		properties += fmt.Sprint(";linenumber=", *input.Line)
+	}
+	if input.Col != nil {
+		properties += fmt.Sprint(";columnnumber=", *input.Col)
+	}
+	if input.Title != nil {
+		message = *input.Title + ": " + message
+	}
+
+	return fmt.Sprintf("##vso[task.logissue %s]%s", properties, azureEscapeData(message))
+}
+
+// StartGroup groups together log messages.
+func (l *AzurePipelinesLogger) StartGroup(name string) {
+	l.info.Printf("##[group]%s", name)
+}
+
+// EndGroup ends a group.
+func (l *AzurePipelinesLogger) EndGroup() {
+	l.info.Println("##[endgroup]")
+}
+
+// Mask masks a value in log output.
+func (l *AzurePipelinesLogger) Mask(value string) {
+	if value == "" {
+		return
+	}
+	l.info.Printf("##vso[task.setsecret]%s", azureEscapeData(value))
+}
+
+// SetOutput sets an output variable.
+func (l *AzurePipelinesLogger) SetOutput(name string, value string) {
+	l.info.Printf("##vso[task.setvariable variable=%s;isOutput=true]%s", azureEscapeProperty(name), azureEscapeData(value))
+}
+
+// SetSummary attaches a markdown summary to the build.
+func (l *AzurePipelinesLogger) SetSummary(summary string) {
+	tempDir := os.Getenv("AGENT_TEMPDIRECTORY")
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+
+	// Summaries are uploaded from a file, each one gets its own section
+	f, err := os.CreateTemp(tempDir, "gbt-summary-*.md")
+	if err != nil {
+		l.Errorf("failed to create summary file: %v", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(summary); err != nil {
+		l.Errorf("failed to write summary file: %v", err)
+		return
+	}
+
+	l.info.Printf("##vso[task.uploadsummary]%s", azureEscapeData(f.Name()))
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	FormatAuto     = "auto"
+	FormatPlain    = "plain"
+	FormatGitHub   = "github"
+	FormatGitLab   = "gitlab"
+	FormatAzure    = "azure"
+	FormatTeamCity = "teamcity"
+	FormatJSON     = "json"
+)
+
+// Formats lists the log formats that can be selected with Detect.
+var Formats []string = []string{FormatAuto, FormatPlain, FormatGitHub, FormatGitLab, FormatAzure, FormatTeamCity, FormatJSON}
+
+// DetectFormat returns the log format of the CI system the process is running in,
+// falling back to plain output.
+func DetectFormat() string {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return FormatGitHub
+	case os.Getenv("GITLAB_CI") == "true":
+		return FormatGitLab
+	case strings.EqualFold(os.Getenv("TF_BUILD"), "true"):
+		return FormatAzure
+	case os.Getenv("TEAMCITY_VERSION") != "":
+		return FormatTeamCity
+	}
+	return FormatPlain
+}
+
+// Detect creates a logger for the given format, detecting the CI system when the format is
+// empty or auto. Debug logging is also enabled when the CI system runs in debug mode.
+func Detect(format string, options *LoggerOptions) (Logger, error) {
+	if format == "" || format == FormatAuto {
+		format = DetectFormat()
+	}
+
+	var loggerOptions LoggerOptions = *options
+	if os.Getenv("RUNNER_DEBUG") == "1" {
+		loggerOptions.Debug = true
+	}
+
+	switch format {
+	case FormatPlain:
+		return NewLogger(&loggerOptions), nil
+	case FormatGitHub:
+		return NewGitHubActionsLogger(loggerOptions.Debug), nil
+	case FormatGitLab:
+		return NewGitLabLogger(&loggerOptions), nil
+	case FormatAzure:
+		return NewAzurePipelinesLogger(loggerOptions.Debug), nil
+	case FormatTeamCity:
+		return NewTeamCityLogger(loggerOptions.Debug), nil
+	case FormatJSON:
+		return NewJSONLogger(&loggerOptions), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"fmt"
+	"testing"
+)
+
+// loggerDebug returns true if the logger has debug logging enabled.
+func loggerDebug(logger Logger) bool {
+	switch l := logger.(type) {
+	case *DefaultLogger:
+		return l.debug != nil
+	case *GitHubActionsLogger:
+		return l.debug
+	case *GitLabLogger:
+		return l.debug != nil
+	case *AzurePipelinesLogger:
+		return l.debug != nil
+	case *TeamCityLogger:
+		return l.debug
+	case *JSONLogger:
+		return l.debug
+	}
+	panic(fmt.Sprintf("unexpected logger %T", logger))
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		format   string
+		verbose  bool
+		expected string
+		debug    bool
+	}{
+		{"no CI", nil, "", false, "*logging.DefaultLogger", false},
+		{"GitHub Actions", map[string]string{"GITHUB_ACTIONS": "true"}, "", false, "*logging.GitHubActionsLogger", false},
+		{"GitLab CI", map[string]string{"GITLAB_CI": "true"}, FormatAuto, false, "*logging.GitLabLogger", false},
+		{"Azure Pipelines", map[string]string{"TF_BUILD": "True"}, "", false, "*logging.AzurePipelinesLogger", false},
+		{"TeamCity", map[string]string{"TEAMCITY_VERSION": "2023.11"}, "", false, "*logging.TeamCityLogger", false},
+		{"GITHUB_ACTIONS not true", map[string]string{"GITHUB_ACTIONS": "false"}, "", false, "*logging.DefaultLogger", false},
+
+		// Checked in order: GitHub Actions, GitLab CI, Azure Pipelines, then TeamCity
+		{"GitHub Actions over GitLab CI", map[string]string{"GITHUB_ACTIONS": "true", "GITLAB_CI": "true", "TF_BUILD": "True", "TEAMCITY_VERSION": "2023.11"}, "", false, "*logging.GitHubActionsLogger", false},
+		{"GitLab CI over Azure Pipelines", map[string]string{"GITLAB_CI": "true", "TF_BUILD": "True", "TEAMCITY_VERSION": "2023.11"}, "", false, "*logging.GitLabLogger", false},
+		{"Azure Pipelines over TeamCity", map[string]string{"TF_BUILD": "true", "TEAMCITY_VERSION": "2023.11"}, "", false, "*logging.AzurePipelinesLogger", false},
+
+		// --log-format overrides detection
+		{"plain in GitHub Actions", map[string]string{"GITHUB_ACTIONS": "true"}, FormatPlain, false, "*logging.DefaultLogger", false},
+		{"gitlab in GitHub Actions", map[string]string{"GITHUB_ACTIONS": "true"}, FormatGitLab, false, "*logging.GitLabLogger", false},
+		{"github outside of CI", nil, FormatGitHub, false, "*logging.GitHubActionsLogger", false},
+		{"azure", nil, FormatAzure, false, "*logging.AzurePipelinesLogger", false},
+		{"teamcity", nil, FormatTeamCity, false, "*logging.TeamCityLogger", false},
+		{"json in TeamCity", map[string]string{"TEAMCITY_VERSION": "2023.11"}, FormatJSON, false, "*logging.JSONLogger", false},
+
+		// RUNNER_DEBUG=1 enables debug logging like -verbose
+		{"verbose", nil, "", true, "*logging.DefaultLogger", true},
+		{"RUNNER_DEBUG", map[string]string{"GITHUB_ACTIONS": "true", "RUNNER_DEBUG": "1"}, "", false, "*logging.GitHubActionsLogger", true},
+		{"RUNNER_DEBUG with a format", map[string]string{"RUNNER_DEBUG": "1"}, FormatJSON, false, "*logging.JSONLogger", true},
+		{"RUNNER_DEBUG not 1", map[string]string{"GITLAB_CI": "true", "RUNNER_DEBUG": "0"}, "", false, "*logging.GitLabLogger", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range []string{"GITHUB_ACTIONS", "GITLAB_CI", "TF_BUILD", "TEAMCITY_VERSION", "RUNNER_DEBUG"} {
+				t.Setenv(name, test.env[name])
+			}
+
+			logger, err := Detect(test.format, &LoggerOptions{Debug: test.verbose})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := fmt.Sprintf("%T", logger); actual != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, actual)
+			}
+			if loggerDebug(logger) != test.debug {
+				t.Errorf("expected debug logging to be %v", test.debug)
+			}
+		})
+	}
+}
+
+func TestDetectUnknownFormat(t *testing.T) {
+	if _, err := Detect("xml", &LoggerOptions{}); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
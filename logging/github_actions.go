@@ -1,97 +1,171 @@
 package logging
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 )
 
 // GitHubActionsLogger is a logger that logs to GitHub Actions.
 type GitHubActionsLogger struct {
 	info  *log.Logger
-	warn  *log.Logger
 	err   *log.Logger
-	debug *log.Logger
+	debug bool
 }
 
 // NewGitHubActionsLogger creates a new GitHubActionsLogger.
 func NewGitHubActionsLogger(debug bool) Logger {
-	var debugLogger *log.Logger
-	if debug {
-		debugLogger = log.New(os.Stdout, "::debug::", 0)
-	}
-
 	return &GitHubActionsLogger{
 		info:  log.New(os.Stdout, "", 0),
-		warn:  log.New(os.Stdout, "::warning::", 0),
-		err:   log.New(os.Stderr, "::error::", 0),
-		debug: debugLogger,
+		err:   log.New(os.Stderr, "", 0),
+		debug: debug,
 	}
 }
 
-// Infof logs an info message.
-func (l *GitHubActionsLogger) Infof(format string, args ...interface{}) {
-	l.info.Printf(format, args...)
+// escapeData escapes a workflow command's message so it can't end the command or span lines.
+func escapeData(value string) string {
+	value = strings.ReplaceAll(value, "%", "%25")
+	value = strings.ReplaceAll(value, "\r", "%0D")
+	return strings.ReplaceAll(value, "\n", "%0A")
 }
 
-// Warnf logs a warning message.
-func (l *GitHubActionsLogger) Warnf(format string, args ...interface{}) {
-	l.warn.Printf(format, args...)
+// escapeProperty escapes a workflow command's property value.
+func escapeProperty(value string) string {
+	value = escapeData(value)
+	value = strings.ReplaceAll(value, ":", "%3A")
+	return strings.ReplaceAll(value, ",", "%2C")
 }
 
-// Errorf logs an error message.
-func (l *GitHubActionsLogger) Errorf(format string, args ...interface{}) {
-	l.err.Printf(format, args...)
-}
-
-// Debugf logs a debug message if debug logging is enabled.
-func (l *GitHubActionsLogger) Debugf(format string, args ...interface{}) {
-	if l.debug != nil {
-		l.debug.Printf(format, args...)
+// command formats a workflow command with its properties, which are given as name and value pairs.
+func command(name string, properties []string, message string) string {
+	var b strings.Builder
+	b.WriteString("::")
+	b.WriteString(name)
+	for i := 0; i+1 < len(properties); i += 2 {
+		if i == 0 {
+			b.WriteString(" ")
+		} else {
+			b.WriteString(",")
+		}
+		b.WriteString(properties[i])
+		b.WriteString("=")
+		b.WriteString(escapeProperty(properties[i+1]))
 	}
+	b.WriteString("::")
+	b.WriteString(escapeData(message))
+	return b.String()
 }
 
-// NoticeMessage sends a notice message to GitHub Actions.
-func (l *GitHubActionsLogger) NoticeMessage(message string, input NoticeMessageInput) {
-	var prefix string = "::notice"
+// annotationProperties returns the properties of an annotation command for the given input.
+func annotationProperties(input NoticeMessageInput) []string {
+	input = normalizeInput(input)
+
+	var properties []string
 	if input.Title != nil {
-		prefix += " title=" + *input.Title
+		properties = append(properties, "title", *input.Title)
 	}
 	if input.Filename != nil {
-		prefix += " file=" + *input.Filename
+		properties = append(properties, "file", *input.Filename)
 	}
 	if input.Line != nil {
-		prefix += " line=" + fmt.Sprint(*input.Line)
+		properties = append(properties, "line", fmt.Sprint(*input.Line))
 	}
 	if input.EndLine != nil {
-		prefix += " endLine=" + fmt.Sprint(*input.EndLine)
+		properties = append(properties, "endLine", fmt.Sprint(*input.EndLine))
 	}
 	if input.Col != nil {
-		prefix += " col=" + fmt.Sprint(*input.Col)
+		properties = append(properties, "col", fmt.Sprint(*input.Col))
 	}
 	if input.EndCol != nil {
-		prefix += " endColumn=" + fmt.Sprint(*input.EndCol)
+		properties = append(properties, "endColumn", fmt.Sprint(*input.EndCol))
 	}
+	return properties
+}
 
-	l.info.Printf("%s::%s", prefix, message)
+// Infof logs an info message. Messages carry Godot's output, so lines that would be read as
+// workflow commands are logged with command processing stopped.
+func (l *GitHubActionsLogger) Infof(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if !containsCommand(message) {
+		l.info.Print(message)
+		return
+	}
+
+	token, err := randomToken("gbt_", message)
+	if err != nil {
+		l.info.Print(strings.NewReplacer("::", ": :", "##[", "# #[").Replace(message))
+		return
+	}
+	l.info.Print(command("stop-commands", nil, token))
+	l.info.Print(message)
+	l.info.Print(command(token, nil, ""))
+}
+
+// containsCommand returns true if a line of the message would be read as a workflow command.
+func containsCommand(message string) bool {
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimLeft(line, " \t\r")
+		if strings.HasPrefix(line, "::") || strings.HasPrefix(line, "##[") {
+			return true
+		}
+	}
+	return false
+}
+
+// Warnf logs a warning message.
+func (l *GitHubActionsLogger) Warnf(format string, args ...interface{}) {
+	l.info.Print(command("warning", nil, fmt.Sprintf(format, args...)))
+}
+
+// Errorf logs an error message.
+func (l *GitHubActionsLogger) Errorf(format string, args ...interface{}) {
+	l.err.Print(command("error", nil, fmt.Sprintf(format, args...)))
+}
+
+// Debugf logs a debug message if debug logging is enabled.
+func (l *GitHubActionsLogger) Debugf(format string, args ...interface{}) {
+	if l.debug {
+		l.info.Print(command("debug", nil, fmt.Sprintf(format, args...)))
+	}
+}
+
+// NoticeMessage sends a notice message to GitHub Actions.
+func (l *GitHubActionsLogger) NoticeMessage(message string, input NoticeMessageInput) {
+	l.info.Print(command("notice", annotationProperties(input), message))
+}
+
+// WarningAnnotation sends a warning about a line to GitHub Actions.
+func (l *GitHubActionsLogger) WarningAnnotation(message string, input NoticeMessageInput) {
+	l.info.Print(command("warning", annotationProperties(input), message))
+}
+
+// ErrorAnnotation sends an error about a line to GitHub Actions.
+func (l *GitHubActionsLogger) ErrorAnnotation(message string, input NoticeMessageInput) {
+	l.err.Print(command("error", annotationProperties(input), message))
 }
 
 // StartGroup groups together log messages.
 func (l *GitHubActionsLogger) StartGroup(name string) {
-	l.info.Printf("::group::%s", name)
+	l.info.Print(command("group", nil, name))
 }
 
 // EndGroup ends a group.
 func (l *GitHubActionsLogger) EndGroup() {
-	l.info.Println("::endgroup::")
+	l.info.Print(command("endgroup", nil, ""))
 }
 
 // Mask masks a value in log output.
 func (l *GitHubActionsLogger) Mask(value string) {
-	l.info.Printf("::add-mask::%s", value)
+	if value == "" {
+		return
+	}
+	l.info.Print(command("add-mask", nil, value))
 }
 
-// SetOutput sets an output parameter.
+// SetOutput sets an output parameter. Values are written with a delimiter, so they may span lines.
 func (l *GitHubActionsLogger) SetOutput(name string, value string) {
 	outputFile := os.Getenv("GITHUB_OUTPUT")
 	if outputFile == "" {
@@ -99,6 +173,12 @@ func (l *GitHubActionsLogger) SetOutput(name string, value string) {
 		return
 	}
 
+	delimiter, err := randomToken("ghadelimiter_", name+"\n"+value)
+	if err != nil {
+		l.Errorf("failed to set output %s: %v", name, err)
+		return
+	}
+
 	f, err := os.OpenFile(outputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		l.Errorf("failed to open output file: %v", err)
@@ -106,13 +186,28 @@ func (l *GitHubActionsLogger) SetOutput(name string, value string) {
 	}
 	defer f.Close()
 
-	if _, err := f.WriteString(fmt.Sprint(name, "=", value)); err != nil {
+	if _, err := f.WriteString(fmt.Sprintf("%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter)); err != nil {
 		l.Errorf("failed to write output file: %v", err)
 		return
 	}
 
 }
 
+// randomToken returns a random token with the given prefix, such as an output's delimiter, one
+// that doesn't appear in the content it delimits.
+func randomToken(prefix string, content string) (string, error) {
+	var data []byte = make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		return "", fmt.Errorf("failed to generate delimiter: %s", err)
+	}
+
+	token := prefix + hex.EncodeToString(data)
+	if strings.Contains(content, token) {
+		return "", fmt.Errorf("content contains its delimiter")
+	}
+	return token, nil
+}
+
 // SetSummary sets a job's summary in markdown format.
 func (l *GitHubActionsLogger) SetSummary(summary string) {
 	summaryFile := os.Getenv("GITHUB_STEP_SUMMARY")
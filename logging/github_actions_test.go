@@ -0,0 +1,150 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestGitHubActionsLogger creates a GitHub Actions logger writing to a buffer.
+func newTestGitHubActionsLogger() (*GitHubActionsLogger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return &GitHubActionsLogger{
+		info: log.New(&buf, "", 0),
+		err:  log.New(&buf, "", 0),
+	}, &buf
+}
+
+func TestEscapeData(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"plain message", "plain message"},
+		{"100%", "100%25"},
+		{"first\nsecond", "first%0Asecond"},
+		{"windows\r\nline", "windows%0D%0Aline"},
+		{"%0A", "%250A"},
+		{"a::b, c", "a::b, c"},
+	}
+
+	for _, test := range tests {
+		if got := escapeData(test.value); got != test.expected {
+			t.Errorf("escapeData(%q): expected %q, got %q", test.value, test.expected, got)
+		}
+	}
+}
+
+func TestEscapeProperty(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"res://main.gd", "res%3A//main.gd"},
+		{"a,b", "a%2Cb"},
+		{"50%\nnext", "50%25%0Anext"},
+	}
+
+	for _, test := range tests {
+		if got := escapeProperty(test.value); got != test.expected {
+			t.Errorf("escapeProperty(%q): expected %q, got %q", test.value, test.expected, got)
+		}
+	}
+}
+
+func TestGitHubActionsAnnotation(t *testing.T) {
+	logger, buf := newTestGitHubActionsLogger()
+	title := "SCRIPT ERROR"
+	file := "main.gd"
+	line := 12
+
+	logger.ErrorAnnotation("Invalid call\n::set-output name=x::y", NoticeMessageInput{Title: &title, Filename: &file, Line: &line})
+
+	expected := "::error title=SCRIPT ERROR,file=main.gd,line=12::Invalid call%0A::set-output name=x::y\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestGitHubActionsInfofStopsCommands(t *testing.T) {
+	logger, buf := newTestGitHubActionsLogger()
+
+	for _, line := range []string{"::add-mask::secret", "  ::error::injected", "##[set-output name=x;]y"} {
+		buf.Reset()
+		logger.Infof("%s", line)
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("%q: expected the line between stop-commands and its token, got %q", line, lines)
+		}
+		token, found := strings.CutPrefix(lines[0], "::stop-commands::")
+		if !found || token == "" {
+			t.Fatalf("%q: expected stop-commands first, got %q", line, lines[0])
+		}
+		if lines[1] != line {
+			t.Errorf("%q: expected the line to be logged as is, got %q", line, lines[1])
+		}
+		if lines[2] != "::"+token+"::" {
+			t.Errorf("%q: expected commands to resume with %s, got %q", line, token, lines[2])
+		}
+	}
+
+	buf.Reset()
+	logger.Infof("Exporting %s", "project")
+	if buf.String() != "Exporting project\n" {
+		t.Errorf("expected plain messages to be logged as is, got %q", buf.String())
+	}
+}
+
+func TestGitHubActionsSetOutput(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "output")
+	t.Setenv("GITHUB_OUTPUT", outputFile)
+	logger, _ := newTestGitHubActionsLogger()
+
+	logger.SetOutput("godot-version", "4.2.1")
+	logger.SetOutput("notes", "first line\nsecond line\nother=value")
+
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("failed to read outputs: %s", err)
+	}
+
+	outputs := parseGitHubOutputs(t, string(content))
+	if outputs["godot-version"] != "4.2.1" {
+		t.Errorf("expected godot-version 4.2.1, got %q", outputs["godot-version"])
+	}
+	if outputs["notes"] != "first line\nsecond line\nother=value" {
+		t.Errorf("expected the multi-line value intact, got %q", outputs["notes"])
+	}
+	if _, ok := outputs["other"]; ok {
+		t.Errorf("expected a line of a value not to set an output")
+	}
+}
+
+// parseGitHubOutputs reads a GITHUB_OUTPUT file the way the runner does, with every value
+// written as name<<delimiter.
+func parseGitHubOutputs(t *testing.T, content string) map[string]string {
+	t.Helper()
+
+	outputs := make(map[string]string)
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		name, delimiter, found := strings.Cut(lines[i], "<<")
+		if !found || !strings.HasPrefix(delimiter, "ghadelimiter_") {
+			t.Fatalf("expected name<<delimiter, got %q", lines[i])
+		}
+
+		var value []string
+		for i++; i < len(lines) && lines[i] != delimiter; i++ {
+			value = append(value, lines[i])
+		}
+		if i == len(lines) {
+			t.Fatalf("output %s is missing its closing delimiter", name)
+		}
+		outputs[name] = strings.Join(value, "\n")
+	}
+	return outputs
+}
@@ -0,0 +1,273 @@
+package logging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultGitLabOutputsFile is the dotenv report outputs are written to.
+	DefaultGitLabOutputsFile = "gbt.env"
+	// DefaultGitLabSummaryFile is the markdown artifact the summary is written to.
+	DefaultGitLabSummaryFile = "gbt-summary.md"
+	// DefaultGitLabCodeQualityFile is the Code Quality report notices are written to.
+	DefaultGitLabCodeQualityFile = "gl-code-quality-report.json"
+)
+
+// invalidSectionID matches the characters GitLab doesn't allow in section names.
+var invalidSectionID = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// invalidDotenvName matches the characters that aren't allowed in dotenv variable names.
+var invalidDotenvName = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// GitLabLogger is a logger that logs to GitLab CI, with collapsible sections and reports
+// written to files for the job to upload as artifacts.
+type GitLabLogger struct {
+	info  *log.Logger
+	warn  *log.Logger
+	err   *log.Logger
+	debug *log.Logger
+
+	outputsFile     string
+	summaryFile     string
+	codeQualityFile string
+
+	sections     []string
+	sectionCount int
+	masks        []string
+	issues       []gitLabCodeQualityIssue
+}
+
+// gitLabCodeQualityIssue is an entry of a GitLab Code Quality report.
+type gitLabCodeQualityIssue struct {
+	Description string                    `json:"description"`
+	CheckName   string                    `json:"check_name"`
+	Fingerprint string                    `json:"fingerprint"`
+	Severity    string                    `json:"severity"`
+	Location    gitLabCodeQualityLocation `json:"location"`
+}
+
+type gitLabCodeQualityLocation struct {
+	Path  string                 `json:"path"`
+	Lines gitLabCodeQualityLines `json:"lines"`
+}
+
+type gitLabCodeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+// NewGitLabLogger creates a new GitLabLogger. Files that aren't set in the options default to
+// DefaultGitLabOutputsFile, DefaultGitLabSummaryFile and DefaultGitLabCodeQualityFile.
+func NewGitLabLogger(options *LoggerOptions) Logger {
+	var debugLogger *log.Logger
+	if options.Debug {
+		debugLogger = log.New(os.Stdout, "DEBUG ", 0)
+	}
+
+	var outputsFile string = options.OutputsFile
+	if outputsFile == "" {
+		outputsFile = DefaultGitLabOutputsFile
+	}
+	var summaryFile string = options.SummaryFile
+	if summaryFile == "" {
+		summaryFile = DefaultGitLabSummaryFile
+	}
+	var codeQualityFile string = options.CodeQualityFile
+	if codeQualityFile == "" {
+		codeQualityFile = DefaultGitLabCodeQualityFile
+	}
+
+	// Outputs and the summary are appended to, and the Code Quality report is only written
+	// once there is a notice, so files from an earlier run would otherwise be reported again
+	if options.ResetReports {
+		for _, reportFile := range []string{outputsFile, summaryFile, codeQualityFile} {
+			_ = os.Remove(reportFile)
+		}
+	}
+
+	return &GitLabLogger{
+		info:  log.New(os.Stdout, "", 0),
+		warn:  log.New(os.Stdout, "\x1b[33mWARNING ", 0),
+		err:   log.New(os.Stderr, "\x1b[31mERROR ", 0),
+		debug: debugLogger,
+
+		outputsFile:     outputsFile,
+		summaryFile:     summaryFile,
+		codeQualityFile: codeQualityFile,
+
+		sections: []string{},
+		masks:    []string{},
+		issues:   []gitLabCodeQualityIssue{},
+	}
+}
+
+// removeMasks removes any masked values from the message, GitLab only masks its own variables.
+func (l *GitLabLogger) removeMasks(message string) string {
+	return removeMasks(message, l.masks)
+}
+
+// Infof logs an info message.
+func (l *GitLabLogger) Infof(format string, args ...interface{}) {
+	l.info.Print(l.removeMasks(fmt.Sprintf(format, args...)))
+}
+
+// Warnf logs a warning message.
+func (l *GitLabLogger) Warnf(format string, args ...interface{}) {
+	l.warn.Print(l.removeMasks(fmt.Sprintf(format, args...)) + "\x1b[0m")
+}
+
+// Errorf logs an error message.
+func (l *GitLabLogger) Errorf(format string, args ...interface{}) {
+	l.err.Print(l.removeMasks(fmt.Sprintf(format, args...)) + "\x1b[0m")
+}
+
+// Debugf logs a debug message if debug logging is enabled.
+func (l *GitLabLogger) Debugf(format string, args ...interface{}) {
+	if l.debug != nil {
+		l.debug.Print(l.removeMasks(fmt.Sprintf(format, args...)))
+	}
+}
+
+// NoticeMessage logs a notice about a line and adds it to the Code Quality report, so it
+// shows up in merge requests.
+func (l *GitLabLogger) NoticeMessage(message string, input NoticeMessageInput) {
+	l.annotate("info", l.Infof, message, input)
+}
+
+// WarningAnnotation logs a warning about a line and adds it to the Code Quality report.
+func (l *GitLabLogger) WarningAnnotation(message string, input NoticeMessageInput) {
+	l.annotate("minor", l.Warnf, message, input)
+}
+
+// ErrorAnnotation logs an error about a line and adds it to the Code Quality report.
+func (l *GitLabLogger) ErrorAnnotation(message string, input NoticeMessageInput) {
+	l.annotate("major", l.Errorf, message, input)
+}
+
+// annotate logs a message about a line and adds it to the Code Quality report with the given severity.
+func (l *GitLabLogger) annotate(severity string, logf func(format string, args ...interface{}), message string, input NoticeMessageInput) {
+	input = normalizeInput(input)
+	message = l.removeMasks(message)
+
+	var checkName string = "gbt"
+	if input.Title != nil {
+		checkName = l.removeMasks(*input.Title)
+	}
+
+	var location string
+	var path string
+	var line int = 1
+	if input.Filename != nil {
+		path = *input.Filename
+		location = path
+		if input.Line != nil {
+			line = *input.Line
+			location += fmt.Sprint(":", line)
+		}
+		location += ": "
+	}
+	logf("%s%s: %s", location, checkName, message)
+
+	// Code Quality entries need a file, so notices without one are only logged
+	if path == "" {
+		return
+	}
+
+	fingerprint := sha256.Sum256([]byte(fmt.Sprintf("%s:%d:%s:%s", path, line, checkName, message)))
+	l.issues = append(l.issues, gitLabCodeQualityIssue{
+		Description: message,
+		CheckName:   checkName,
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		Severity:    severity,
+		Location: gitLabCodeQualityLocation{
+			Path:  path,
+			Lines: gitLabCodeQualityLines{Begin: line},
+		},
+	})
+
+	// The report is a single JSON array, so it's rewritten with every entry
+	data, err := json.MarshalIndent(l.issues, "", "  ")
+	if err != nil {
+		l.Errorf("failed to encode code quality report: %s", err)
+		return
+	}
+	if err := os.WriteFile(l.codeQualityFile, data, 0644); err != nil {
+		l.Errorf("failed to write code quality report: %s", err)
+	}
+}
+
+// StartGroup starts a collapsed section.
+func (l *GitLabLogger) StartGroup(name string) {
+	var id string = strings.Trim(invalidSectionID.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if id == "" {
+		id = "section"
+	}
+	// Section IDs have to be unique within the job
+	l.sectionCount++
+	id = fmt.Sprintf("%s_%d", id, l.sectionCount)
+
+	l.sections = append(l.sections, id)
+	l.info.Printf("\x1b[0Ksection_start:%d:%s[collapsed=true]\r\x1b[0K%s", time.Now().Unix(), id, l.removeMasks(name))
+}
+
+// EndGroup ends the current section.
+func (l *GitLabLogger) EndGroup() {
+	if len(l.sections) == 0 {
+		return
+	}
+	id := l.sections[len(l.sections)-1]
+	l.sections = l.sections[:len(l.sections)-1]
+	l.info.Printf("\x1b[0Ksection_end:%d:%s\r\x1b[0K", time.Now().Unix(), id)
+}
+
+// Mask hides a value in the log output.
+func (l *GitLabLogger) Mask(value string) {
+	if value == "" {
+		return
+	}
+	l.masks = append(l.masks, value)
+}
+
+// SetOutput writes an output to the dotenv report, as a variable such as GODOT_VERSION for
+// an output named godot-version.
+func (l *GitLabLogger) SetOutput(name string, value string) {
+	if strings.ContainsAny(value, "\r\n") {
+		l.Warnf("Output %s spans several lines, which dotenv reports don't support", name)
+		return
+	}
+
+	f, err := os.OpenFile(l.outputsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		l.Errorf("failed to open outputs file: %s", err)
+		return
+	}
+	defer f.Close()
+
+	var variable string = strings.ToUpper(invalidDotenvName.ReplaceAllString(name, "_"))
+	if _, err := f.WriteString(fmt.Sprintf("%s=%s\n", variable, value)); err != nil {
+		l.Errorf("failed to write to outputs file: %s", err)
+		return
+	}
+}
+
+// SetSummary writes a markdown summary to the summary file.
+func (l *GitLabLogger) SetSummary(summary string) {
+	f, err := os.OpenFile(l.summaryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		l.Errorf("failed to open summary file: %s", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(summary); err != nil {
+		l.Errorf("failed to write to summary file: %s", err)
+		return
+	}
+}
@@ -0,0 +1,176 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// newTestGitLabLogger creates a GitLab logger writing to a buffer, with its reports in a temporary directory.
+func newTestGitLabLogger(t *testing.T) (*GitLabLogger, *bytes.Buffer) {
+	dir := t.TempDir()
+
+	var buf bytes.Buffer
+	return &GitLabLogger{
+		info: log.New(&buf, "", 0),
+		warn: log.New(&buf, "WARNING ", 0),
+		err:  log.New(&buf, "ERROR ", 0),
+
+		outputsFile:     filepath.Join(dir, DefaultGitLabOutputsFile),
+		summaryFile:     filepath.Join(dir, DefaultGitLabSummaryFile),
+		codeQualityFile: filepath.Join(dir, DefaultGitLabCodeQualityFile),
+
+		sections: []string{},
+		masks:    []string{},
+		issues:   []gitLabCodeQualityIssue{},
+	}, &buf
+}
+
+var gitLabSection = regexp.MustCompile(`section_(start|end):\d+:([^\[\r]+)`)
+
+func TestGitLabSectionIDs(t *testing.T) {
+	logger, buf := newTestGitLabLogger(t)
+
+	logger.StartGroup("Export: Linux/X11 (release)")
+	logger.StartGroup("Export: Linux/X11 (release)")
+	logger.EndGroup()
+	logger.EndGroup()
+	logger.StartGroup("::")
+	logger.EndGroup()
+	logger.EndGroup()
+
+	var sections [][]string
+	for _, match := range gitLabSection.FindAllStringSubmatch(buf.String(), -1) {
+		sections = append(sections, match[1:])
+	}
+
+	expected := [][]string{
+		{"start", "export_linux_x11_release_1"},
+		{"start", "export_linux_x11_release_2"},
+		{"end", "export_linux_x11_release_2"},
+		{"end", "export_linux_x11_release_1"},
+		{"start", "section_3"},
+		{"end", "section_3"},
+	}
+	if len(sections) != len(expected) {
+		t.Fatalf("expected sections %v, got %v", expected, sections)
+	}
+	for i := range expected {
+		if sections[i][0] != expected[i][0] || sections[i][1] != expected[i][1] {
+			t.Errorf("expected section %v, got %v", expected[i], sections[i])
+		}
+	}
+}
+
+func TestGitLabSetOutput(t *testing.T) {
+	logger, buf := newTestGitLabLogger(t)
+
+	logger.SetOutput("godot-version", "4.2.1")
+	logger.SetOutput("export.path", "build/game.x86_64")
+	logger.SetOutput("notes", "first\nsecond")
+
+	data, err := os.ReadFile(logger.outputsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "GODOT_VERSION=4.2.1\nEXPORT_PATH=build/game.x86_64\n"
+	if string(data) != expected {
+		t.Errorf("expected outputs %q, got %q", expected, string(data))
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("WARNING Output notes spans several lines")) {
+		t.Errorf("expected a warning about the multi-line output, got %q", buf.String())
+	}
+}
+
+func TestGitLabCodeQualityReport(t *testing.T) {
+	logger, _ := newTestGitLabLogger(t)
+	logger.Mask("hunter2")
+
+	title := "SCRIPT ERROR"
+	file := "scripts/player.gd"
+	line := 12
+	logger.ErrorAnnotation("Invalid token hunter2", NoticeMessageInput{Title: &title, Filename: &file, Line: &line})
+	logger.WarningAnnotation("Unused variable", NoticeMessageInput{Filename: &file})
+	logger.NoticeMessage("Not in a file", NoticeMessageInput{})
+
+	data, err := os.ReadFile(logger.codeQualityFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var issues []gitLabCodeQualityIssue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		t.Fatalf("failed to decode code quality report: %s", err)
+	}
+
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %d: %s", len(issues), data)
+	}
+
+	if issues[0].Description != "Invalid token ********" || issues[0].CheckName != title || issues[0].Severity != "major" {
+		t.Errorf("unexpected first issue: %+v", issues[0])
+	}
+	if issues[0].Location.Path != file || issues[0].Location.Lines.Begin != line {
+		t.Errorf("unexpected first issue location: %+v", issues[0].Location)
+	}
+
+	if issues[1].CheckName != "gbt" || issues[1].Severity != "minor" || issues[1].Location.Lines.Begin != 1 {
+		t.Errorf("unexpected second issue: %+v", issues[1])
+	}
+
+	if len(issues[0].Fingerprint) != 64 || issues[0].Fingerprint == issues[1].Fingerprint {
+		t.Errorf("expected distinct SHA-256 fingerprints, got %q and %q", issues[0].Fingerprint, issues[1].Fingerprint)
+	}
+}
+
+func TestGitLabResetReports(t *testing.T) {
+	dir := t.TempDir()
+	options := &LoggerOptions{
+		OutputsFile:     filepath.Join(dir, "outputs.env"),
+		SummaryFile:     filepath.Join(dir, "summary.md"),
+		CodeQualityFile: filepath.Join(dir, "code-quality.json"),
+	}
+	writeReports := func() {
+		for _, reportFile := range []string{options.OutputsFile, options.SummaryFile, options.CodeQualityFile} {
+			if err := os.WriteFile(reportFile, []byte("from an earlier run"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	writeReports()
+	NewGitLabLogger(options)
+	for _, reportFile := range []string{options.OutputsFile, options.SummaryFile, options.CodeQualityFile} {
+		if _, err := os.Stat(reportFile); err != nil {
+			t.Errorf("expected %s to be kept without ResetReports: %s", reportFile, err)
+		}
+	}
+
+	options.ResetReports = true
+	logger := NewGitLabLogger(options)
+	for _, reportFile := range []string{options.OutputsFile, options.SummaryFile, options.CodeQualityFile} {
+		if _, err := os.Stat(reportFile); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, got %v", reportFile, err)
+		}
+	}
+
+	logger.SetOutput("godot-version", "4.2.1")
+	logger.SetSummary("# Build\n")
+	outputs, err := os.ReadFile(options.OutputsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(outputs) != "GODOT_VERSION=4.2.1\n" {
+		t.Errorf("expected only this run's outputs, got %q", string(outputs))
+	}
+	summary, err := os.ReadFile(options.SummaryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(summary) != "# Build\n" {
+		t.Errorf("expected only this run's summary, got %q", string(summary))
+	}
+}
@@ -0,0 +1,236 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// StepLogger is implemented by loggers that record which build step a message belongs to.
+type StepLogger interface {
+	Logger
+	// SetStep sets the step later messages belong to, an empty name clears it.
+	SetStep(name string)
+}
+
+// JSONLogger is a logger that writes one JSON object per line, for log aggregation.
+type JSONLogger struct {
+	out   io.Writer
+	debug bool
+
+	outputsFile string
+	summaryFile string
+
+	mu     sync.Mutex
+	groups []string
+	step   string
+	masks  []string
+}
+
+// jsonEntry is a single line of JSON log output.
+type jsonEntry struct {
+	Time    string      `json:"time"`
+	Level   string      `json:"level"`
+	Message string      `json:"message"`
+	Groups  []string    `json:"groups,omitempty"`
+	Step    string      `json:"step,omitempty"`
+	Notice  *jsonNotice `json:"notice,omitempty"`
+	Output  *jsonOutput `json:"output,omitempty"`
+}
+
+// jsonNotice holds the fields of a NoticeMessageInput.
+type jsonNotice struct {
+	Title    *string `json:"title,omitempty"`
+	Filename *string `json:"file,omitempty"`
+	Line     *int    `json:"line,omitempty"`
+	EndLine  *int    `json:"end_line,omitempty"`
+	Col      *int    `json:"col,omitempty"`
+	EndCol   *int    `json:"end_col,omitempty"`
+}
+
+// jsonOutput is an output set with SetOutput.
+type jsonOutput struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// NewJSONLogger creates a new JSONLogger writing to stdout.
+func NewJSONLogger(options *LoggerOptions) Logger {
+	return &JSONLogger{
+		out:   os.Stdout,
+		debug: options.Debug,
+
+		outputsFile: options.OutputsFile,
+		summaryFile: options.SummaryFile,
+
+		groups: []string{},
+		masks:  []string{},
+	}
+}
+
+// write writes a log entry, removing any masked values from it.
+func (l *JSONLogger) write(entry jsonEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	entry.Message = removeMasks(entry.Message, l.masks)
+	entry.Step = removeMasks(l.step, l.masks)
+	for _, group := range l.groups {
+		entry.Groups = append(entry.Groups, removeMasks(group, l.masks))
+	}
+	if entry.Notice != nil {
+		entry.Notice.Title = l.removeMasksFrom(entry.Notice.Title)
+		entry.Notice.Filename = l.removeMasksFrom(entry.Notice.Filename)
+	}
+	if entry.Output != nil {
+		entry.Output.Name = removeMasks(entry.Output.Name, l.masks)
+		entry.Output.Value = removeMasks(entry.Output.Value, l.masks)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		data, _ = json.Marshal(jsonEntry{Time: entry.Time, Level: "error", Message: fmt.Sprintf("failed to encode log entry: %s", err)})
+	}
+	_, _ = l.out.Write(append(data, '\n'))
+}
+
+// removeMasksFrom returns a copy of an optional value with any masked values removed,
+// leaving the caller's value as it is.
+func (l *JSONLogger) removeMasksFrom(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	masked := removeMasks(*value, l.masks)
+	return &masked
+}
+
+// Infof logs an info message.
+func (l *JSONLogger) Infof(format string, args ...interface{}) {
+	l.write(jsonEntry{Level: "info", Message: fmt.Sprintf(format, args...)})
+}
+
+// Warnf logs a warning message.
+func (l *JSONLogger) Warnf(format string, args ...interface{}) {
+	l.write(jsonEntry{Level: "warning", Message: fmt.Sprintf(format, args...)})
+}
+
+// Errorf logs an error message.
+func (l *JSONLogger) Errorf(format string, args ...interface{}) {
+	l.write(jsonEntry{Level: "error", Message: fmt.Sprintf(format, args...)})
+}
+
+// Debugf logs a debug message if debug logging is enabled.
+func (l *JSONLogger) Debugf(format string, args ...interface{}) {
+	if l.debug {
+		l.write(jsonEntry{Level: "debug", Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// NoticeMessage logs a notice about a line.
+func (l *JSONLogger) NoticeMessage(message string, input NoticeMessageInput) {
+	l.annotate("notice", message, input)
+}
+
+// WarningAnnotation logs a warning about a line.
+func (l *JSONLogger) WarningAnnotation(message string, input NoticeMessageInput) {
+	l.annotate("warning", message, input)
+}
+
+// ErrorAnnotation logs an error about a line.
+func (l *JSONLogger) ErrorAnnotation(message string, input NoticeMessageInput) {
+	l.annotate("error", message, input)
+}
+
+// annotate logs a message about a line at the given level.
+func (l *JSONLogger) annotate(level string, message string, input NoticeMessageInput) {
+	input = normalizeInput(input)
+	l.write(jsonEntry{
+		Level:   level,
+		Message: message,
+		Notice: &jsonNotice{
+			Title:    input.Title,
+			Filename: input.Filename,
+			Line:     input.Line,
+			EndLine:  input.EndLine,
+			Col:      input.Col,
+			EndCol:   input.EndCol,
+		},
+	})
+}
+
+// Mask hides a value in the log output.
+func (l *JSONLogger) Mask(value string) {
+	if value == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.masks = append(l.masks, value)
+}
+
+// StartGroup groups together log messages.
+func (l *JSONLogger) StartGroup(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.groups = append(l.groups, name)
+}
+
+// EndGroup ends a group.
+func (l *JSONLogger) EndGroup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.groups) > 0 {
+		l.groups = l.groups[:len(l.groups)-1]
+	}
+}
+
+// SetStep sets the build step later messages belong to.
+func (l *JSONLogger) SetStep(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.step = name
+}
+
+// SetOutput logs an output, and writes it to the outputs file if there is one.
+func (l *JSONLogger) SetOutput(name string, value string) {
+	l.write(jsonEntry{Level: "output", Message: name, Output: &jsonOutput{Name: name, Value: value}})
+
+	if l.outputsFile == "" {
+		return
+	}
+
+	f, err := os.OpenFile(l.outputsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		l.Errorf("failed to open outputs file: %s", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(fmt.Sprintf("%s=%s\n", name, value)); err != nil {
+		l.Errorf("failed to write to outputs file: %s", err)
+		return
+	}
+}
+
+// SetSummary outputs a markdown-formatted summary to the summary file.
+func (l *JSONLogger) SetSummary(summary string) {
+	if l.summaryFile == "" {
+		return
+	}
+
+	f, err := os.OpenFile(l.summaryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		l.Errorf("failed to open summary file: %s", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(summary); err != nil {
+		l.Errorf("failed to write to summary file: %s", err)
+		return
+	}
+}
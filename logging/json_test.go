@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestJSONLogger creates a JSON logger writing to a buffer.
+func newTestJSONLogger() (*JSONLogger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return &JSONLogger{
+		out:    &buf,
+		groups: []string{},
+		masks:  []string{},
+	}, &buf
+}
+
+// decodeJSONLines decodes every line the logger wrote.
+func decodeJSONLines(t *testing.T, buf *bytes.Buffer) []jsonEntry {
+	t.Helper()
+
+	var entries []jsonEntry
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var entry jsonEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("failed to decode %q: %s", scanner.Text(), err)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, entry.Time); err != nil {
+			t.Errorf("expected an RFC 3339 time, got %q", entry.Time)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestJSONLogger(t *testing.T) {
+	logger, buf := newTestJSONLogger()
+
+	logger.SetStep("export")
+	logger.StartGroup("Export Linux")
+	logger.Infof("Exporting %s", "game.x86_64")
+	logger.EndGroup()
+	logger.Debugf("not logged")
+	line := 12
+	title := "SCRIPT ERROR"
+	file := "scripts/player.gd"
+	logger.ErrorAnnotation("Parse error", NoticeMessageInput{Title: &title, Filename: &file, Line: &line})
+	logger.SetStep("")
+	logger.SetOutput("godot-version", "4.2.1")
+
+	entries := decodeJSONLines(t, buf)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %s", len(entries), buf.String())
+	}
+
+	if entries[0].Level != "info" || entries[0].Message != "Exporting game.x86_64" || entries[0].Step != "export" {
+		t.Errorf("unexpected info entry: %+v", entries[0])
+	}
+	if len(entries[0].Groups) != 1 || entries[0].Groups[0] != "Export Linux" {
+		t.Errorf("expected the info entry in the Export Linux group, got %v", entries[0].Groups)
+	}
+
+	notice := entries[1].Notice
+	if entries[1].Level != "error" || entries[1].Groups != nil || notice == nil {
+		t.Fatalf("unexpected annotation entry: %+v", entries[1])
+	}
+	if *notice.Title != title || *notice.Filename != file || *notice.Line != line || notice.Col != nil {
+		t.Errorf("unexpected notice: %+v", notice)
+	}
+
+	if entries[2].Level != "output" || entries[2].Step != "" || entries[2].Output == nil || *entries[2].Output != (jsonOutput{Name: "godot-version", Value: "4.2.1"}) {
+		t.Errorf("unexpected output entry: %+v", entries[2])
+	}
+}
+
+func TestJSONLoggerMasks(t *testing.T) {
+	logger, buf := newTestJSONLogger()
+	logger.Mask("hunter2")
+	logger.Mask("")
+
+	logger.SetStep("deploy hunter2")
+	logger.StartGroup("Group hunter2")
+	logger.Warnf("Token is %s", "hunter2")
+	title := "Title hunter2"
+	file := "secrets/hunter2.gd"
+	logger.WarningAnnotation("Found hunter2", NoticeMessageInput{Title: &title, Filename: &file})
+	logger.SetOutput("token-hunter2", "Bearer hunter2")
+
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("expected masked values to be removed, got %s", buf.String())
+	}
+	if title != "Title hunter2" || file != "secrets/hunter2.gd" {
+		t.Errorf("expected the caller's notice input to be left as it is, got %q and %q", title, file)
+	}
+
+	entries := decodeJSONLines(t, buf)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Message != "Token is ********" || entries[0].Step != "deploy ********" || entries[0].Groups[0] != "Group ********" {
+		t.Errorf("unexpected warning entry: %+v", entries[0])
+	}
+	if notice := entries[1].Notice; *notice.Title != "Title ********" || *notice.Filename != "secrets/********.gd" {
+		t.Errorf("unexpected notice: title %q, file %q", *notice.Title, *notice.Filename)
+	}
+	if output := entries[2].Output; output.Name != "token-********" || output.Value != "Bearer ********" {
+		t.Errorf("unexpected output: %+v", output)
+	}
+}
@@ -20,6 +20,10 @@ type Logger interface {
 	EndGroup()
 
 	NoticeMessage(message string, input NoticeMessageInput)
+	// WarningAnnotation logs a warning about a line, annotating it where the CI system supports it.
+	WarningAnnotation(message string, input NoticeMessageInput)
+	// ErrorAnnotation logs an error about a line, annotating it where the CI system supports it.
+	ErrorAnnotation(message string, input NoticeMessageInput)
 	SetOutput(name string, value string)
 	SetSummary(summary string)
 }
@@ -52,7 +56,12 @@ type NoticeMessageInput struct {
 type LoggerOptions struct {
 	OutputsFile string
 	SummaryFile string
-	Debug       bool
+	// CodeQualityFile is where loggers that report notices as code quality issues write them
+	CodeQualityFile string
+	// ResetReports removes the report files a logger writes to when it is created, so reports
+	// left by an earlier run on the same runner don't carry over
+	ResetReports bool
+	Debug        bool
 }
 
 // NewLogger creates a new default logger.
@@ -85,7 +94,12 @@ func (l *DefaultLogger) formatMessage(message string) string {
 
 // removeMasks removes any masked values from the message.
 func (l *DefaultLogger) removeMasks(message string) string {
-	for _, mask := range l.masks {
+	return removeMasks(message, l.masks)
+}
+
+// removeMasks replaces each of the masked values in the message.
+func removeMasks(message string, masks []string) string {
+	for _, mask := range masks {
 		message = strings.ReplaceAll(message, mask, "********")
 	}
 	return message
@@ -101,28 +115,45 @@ func (l *DefaultLogger) addGroups(message string) string {
 
 // Infof logs an info message.
 func (l *DefaultLogger) Infof(format string, args ...interface{}) {
-	l.info.Printf(l.formatMessage(format), args...)
+	l.info.Print(l.formatMessage(fmt.Sprintf(format, args...)))
 }
 
 // Warnf logs a warning message.
 func (l *DefaultLogger) Warnf(format string, args ...interface{}) {
-	l.warn.Printf(l.formatMessage(format), args...)
+	l.warn.Print(l.formatMessage(fmt.Sprintf(format, args...)))
 }
 
 // Errorf logs an error message.
 func (l *DefaultLogger) Errorf(format string, args ...interface{}) {
-	l.err.Printf(l.formatMessage(format), args...)
+	l.err.Print(l.formatMessage(fmt.Sprintf(format, args...)))
 }
 
 // Debugf logs a debug message if debug logging is enabled.
 func (l *DefaultLogger) Debugf(format string, args ...interface{}) {
 	if l.debug != nil {
-		l.debug.Printf(l.formatMessage(format), args...)
+		l.debug.Print(l.formatMessage(fmt.Sprintf(format, args...)))
 	}
 }
 
 // NoticeMessage sends a notice about a line.
 func (l *DefaultLogger) NoticeMessage(message string, input NoticeMessageInput) {
+	l.info.Print(l.formatMessage(fmt.Sprintf("%s %s", noticePrefix(input), message)))
+}
+
+// WarningAnnotation logs a warning about a line.
+func (l *DefaultLogger) WarningAnnotation(message string, input NoticeMessageInput) {
+	l.warn.Print(l.formatMessage(fmt.Sprintf("%s %s", noticePrefix(input), message)))
+}
+
+// ErrorAnnotation logs an error about a line.
+func (l *DefaultLogger) ErrorAnnotation(message string, input NoticeMessageInput) {
+	l.err.Print(l.formatMessage(fmt.Sprintf("%s %s", noticePrefix(input), message)))
+}
+
+// noticePrefix describes where a notice points to, with its file path normalized.
+func noticePrefix(input NoticeMessageInput) string {
+	input = normalizeInput(input)
+
 	var prefix string = ""
 	if input.Title != nil {
 		prefix += " title=" + *input.Title
@@ -142,12 +173,14 @@ func (l *DefaultLogger) NoticeMessage(message string, input NoticeMessageInput)
 	if input.EndCol != nil {
 		prefix += " endColumn=" + fmt.Sprint(*input.EndCol)
 	}
-
-	l.info.Printf(l.formatMessage(fmt.Sprintf("%s %s", prefix, message)))
+	return prefix
 }
 
 // Mask hides a value in the log output.
 func (l *DefaultLogger) Mask(value string) {
+	if value == "" {
+		return
+	}
 	l.masks = append(l.masks, value)
 }
 
@@ -176,7 +209,7 @@ func (l *DefaultLogger) SetOutput(name string, value string) {
 	}
 	defer f.Close()
 
-	if _, err := f.WriteString(fmt.Sprintf("%s=%s", name, value)); err != nil {
+	if _, err := f.WriteString(fmt.Sprintf("%s=%s\n", name, value)); err != nil {
 		l.Errorf("failed to write to outputs file: %s", err)
 		return
 	}
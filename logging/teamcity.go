@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// TeamCityLogger is a logger that logs to TeamCity using service messages.
+type TeamCityLogger struct {
+	out   *log.Logger
+	debug bool
+
+	groups []string
+	masks  []string
+}
+
+// NewTeamCityLogger creates a new TeamCityLogger.
+func NewTeamCityLogger(debug bool) Logger {
+	return &TeamCityLogger{
+		out:    log.New(os.Stdout, "", 0),
+		debug:  debug,
+		groups: []string{},
+		masks:  []string{},
+	}
+}
+
+// teamCityEscape escapes a service message attribute value.
+func teamCityEscape(value string) string {
+	return strings.NewReplacer(
+		"|", "||",
+		"'", "|'",
+		"\n", "|n",
+		"\r", "|r",
+		"[", "|[",
+		"]", "|]",
+	).Replace(value)
+}
+
+// removeMasks removes any masked values from the message, TeamCity can't mask values it isn't told about up front.
+func (l *TeamCityLogger) removeMasks(message string) string {
+	return removeMasks(message, l.masks)
+}
+
+// message logs a service message with the given status.
+func (l *TeamCityLogger) message(status string, text string) {
+	l.out.Printf("##teamcity[message text='%s' status='%s']", teamCityEscape(l.removeMasks(text)), status)
+}
+
+// Infof logs an info message.
+func (l *TeamCityLogger) Infof(format string, args ...interface{}) {
+	l.out.Print(l.removeMasks(fmt.Sprintf(format, args...)))
+}
+
+// Warnf logs a warning message.
+func (l *TeamCityLogger) Warnf(format string, args ...interface{}) {
+	l.message("WARNING", fmt.Sprintf(format, args...))
+}
+
+// Errorf logs an error message.
+func (l *TeamCityLogger) Errorf(format string, args ...interface{}) {
+	l.message("ERROR", fmt.Sprintf(format, args...))
+}
+
+// Debugf logs a debug message if debug logging is enabled.
+func (l *TeamCityLogger) Debugf(format string, args ...interface{}) {
+	if l.debug {
+		l.out.Print("DEBUG " + l.removeMasks(fmt.Sprintf(format, args...)))
+	}
+}
+
+// NoticeMessage logs a notice about a line.
+func (l *TeamCityLogger) NoticeMessage(message string, input NoticeMessageInput) {
+	l.message("NORMAL", annotationText(message, input))
+}
+
+// WarningAnnotation logs a warning about a line.
+func (l *TeamCityLogger) WarningAnnotation(message string, input NoticeMessageInput) {
+	l.message("WARNING", annotationText(message, input))
+}
+
+// ErrorAnnotation logs an error about a line.
+func (l *TeamCityLogger) ErrorAnnotation(message string, input NoticeMessageInput) {
+	l.message("ERROR", annotationText(message, input))
+}
+
+// annotationText prefixes a message with its title and location.
+func annotationText(message string, input NoticeMessageInput) string {
+	input = normalizeInput(input)
+
+	if input.Title != nil {
+		message = *input.Title + ": " + message
+	}
+	if input.Filename != nil {
+		var location string = *input.Filename
+		if input.Line != nil {
+			location += fmt.Sprint(":", *input.Line)
+		}
+		message = location + ": " + message
+	}
+	return message
+}
+
+// StartGroup groups together log messages.
+func (l *TeamCityLogger) StartGroup(name string) {
+	l.groups = append(l.groups, name)
+	l.out.Printf("##teamcity[blockOpened name='%s']", teamCityEscape(l.removeMasks(name)))
+}
+
+// EndGroup ends a group.
+func (l *TeamCityLogger) EndGroup() {
+	if len(l.groups) == 0 {
+		return
+	}
+	name := l.groups[len(l.groups)-1]
+	l.groups = l.groups[:len(l.groups)-1]
+	l.out.Printf("##teamcity[blockClosed name='%s']", teamCityEscape(l.removeMasks(name)))
+}
+
+// Mask hides a value in the log output.
+func (l *TeamCityLogger) Mask(value string) {
+	if value == "" {
+		return
+	}
+	l.masks = append(l.masks, value)
+}
+
+// SetOutput sets a build parameter.
+func (l *TeamCityLogger) SetOutput(name string, value string) {
+	l.out.Printf("##teamcity[setParameter name='%s' value='%s']", teamCityEscape(name), teamCityEscape(value))
+}
+
+// SetSummary publishes a markdown summary as a build artifact.
+func (l *TeamCityLogger) SetSummary(summary string) {
+	f, err := os.CreateTemp("", "gbt-summary-*.md")
+	if err != nil {
+		l.Errorf("failed to create summary file: %s", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(summary); err != nil {
+		l.Errorf("failed to write summary file: %s", err)
+		return
+	}
+
+	l.out.Printf("##teamcity[publishArtifacts '%s']", teamCityEscape(f.Name()))
+}
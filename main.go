@@ -1,31 +1,128 @@
 package main
 
 import (
+	"errors"
+	"os"
+
+	"github.com/yeslayla/godot-build-tools/commands"
 	"github.com/yeslayla/godot-build-tools/internal"
 	"github.com/yeslayla/godot-build-tools/logging"
 	"github.com/yeslayla/godot-build-tools/steps"
 )
 
 func main() {
-	logger := logging.NewLogger(&logging.LoggerOptions{})
+	logger, _ := logging.Detect(logging.FormatAuto, &logging.LoggerOptions{})
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "init":
+			if !commands.Init(logger, os.Args[2:]) {
+				os.Exit(1)
+			}
+			return
+		case "cache":
+			if !commands.Cache(logger, os.Args[2:]) {
+				os.Exit(1)
+			}
+			return
+		case "engines":
+			if !commands.Engines(logger, os.Args[2:]) {
+				os.Exit(1)
+			}
+			return
+		case "fetch":
+			if !commands.Fetch(logger, os.Args[2:]) {
+				os.Exit(1)
+			}
+			return
+		case "use":
+			if !commands.Use(logger, os.Args[2:]) {
+				os.Exit(1)
+			}
+			return
+		}
+	}
 
 	flags := internal.NewBuildFlags(logger)
 	flags.Parse()
 
-	if flags.DebugLog {
-		logger = logging.NewLogger(&logging.LoggerOptions{
-			Debug: true,
-		})
+	buildLogger, err := logging.Detect(flags.LogFormat, &logging.LoggerOptions{
+		ResetReports: true,
+		Debug:        flags.DebugLog,
+	})
+	if err != nil {
+		logger.Errorf("Invalid -log-format: %s", err)
+		os.Exit(internal.ExitCodeConfig)
+	}
+	logger = buildLogger
+
+	if flags.Pipeline != "" && flags.StepsSet() {
+		logger.Errorf("-steps and -pipeline can't be used together")
+		os.Exit(internal.ExitCodeConfig)
+	}
+
+	// Unknown steps are caught before anything is loaded or downloaded
+	registry := steps.DefaultRegistry()
+	plan, err := registry.Plan(flags.Steps())
+	if err != nil {
+		logger.Errorf("Invalid steps: %s", err)
+		os.Exit(internal.ExitCodeConfig)
 	}
 
 	buildConfig := internal.LoadBuildConfig(logger)
+	if flags.Offline {
+		buildConfig.Download.Offline = true
+	}
+
+	if flags.Pipeline != "" {
+		pipeline, ok := buildConfig.Pipeline(flags.Pipeline)
+		if !ok {
+			logger.Errorf("No pipeline named %q in %s", flags.Pipeline, internal.BuildConfigFile)
+			os.Exit(internal.ExitCodeConfig)
+		}
 
-	var targetOS internal.TargetOS = internal.CurrentTargetOS()
+		plan, err = registry.PlanPipeline(pipeline)
+		if err != nil {
+			logger.Errorf("Invalid pipeline: %s", err)
+			os.Exit(internal.ExitCodeConfig)
+		}
+	}
 
-	if flags.HasStep("godot-setup") {
-		steps.GodotSetup(logger, targetOS, buildConfig.Godot.Version, buildConfig.Godot.Release)
-	} else {
-		logger.Debugf("Skipping godot-setup step")
+	ctx := &steps.Context{
+		Logger:     logger,
+		TargetOS:   internal.CurrentTargetOS(),
+		Config:     &buildConfig,
+		ProjectDir: ".",
+		Frozen:     flags.Frozen,
 	}
 
+	if flags.DryRun || flags.PlanJSON != "" {
+		buildPlan, err := steps.DryRun(ctx, plan)
+		if err != nil {
+			var buildErr *steps.BuildError
+			if errors.As(err, &buildErr) {
+				os.Exit(buildErr.ExitCode())
+			}
+			os.Exit(internal.ExitCodeFailure)
+		}
+
+		if flags.DryRun {
+			buildPlan.Log(logger)
+		}
+		if flags.PlanJSON != "" {
+			if err := buildPlan.WriteJSON(flags.PlanJSON); err != nil {
+				logger.Errorf("%s", err)
+				os.Exit(internal.ExitCodeFailure)
+			}
+		}
+		return
+	}
+
+	if err := steps.Run(ctx, plan); err != nil {
+		var buildErr *steps.BuildError
+		if errors.As(err, &buildErr) {
+			os.Exit(buildErr.ExitCode())
+		}
+		os.Exit(internal.ExitCodeFailure)
+	}
 }
@@ -0,0 +1,94 @@
+package steps
+
+// godotResolveStep resolves the Godot version and pins it with the lockfile.
+type godotResolveStep struct{}
+
+func (s *godotResolveStep) Name() string {
+	return StepGodotResolve
+}
+
+func (s *godotResolveStep) Dependencies() []string {
+	return nil
+}
+
+func (s *godotResolveStep) Run(ctx *Context) error {
+	if err := GodotResolve(ctx.Logger, ctx.TargetOS, ctx.Config, ctx.Frozen); err != nil {
+		return err
+	}
+
+	// Check every package up front, so an offline build lists all of the missing ones at once
+	if ctx.Config.Download.Offline {
+		return OfflineCheck(ctx.Logger, ctx.TargetOS, *ctx.Config, ctx.Planned(StepGodotSetup), ctx.Planned(StepExportTemplates))
+	}
+	return nil
+}
+
+// godotSetupStep installs Godot, making it available to later steps.
+type godotSetupStep struct{}
+
+func (s *godotSetupStep) Name() string {
+	return StepGodotSetup
+}
+
+func (s *godotSetupStep) Dependencies() []string {
+	return []string{StepGodotResolve}
+}
+
+func (s *godotSetupStep) Run(ctx *Context) error {
+	godotBin, err := GodotSetup(ctx.Logger, ctx.TargetOS, *ctx.Config)
+	if err != nil {
+		return err
+	}
+	ctx.GodotBin = godotBin
+	return nil
+}
+
+// exportTemplatesStep installs the export templates.
+type exportTemplatesStep struct{}
+
+func (s *exportTemplatesStep) Name() string {
+	return StepExportTemplates
+}
+
+func (s *exportTemplatesStep) Dependencies() []string {
+	return []string{StepGodotResolve}
+}
+
+func (s *exportTemplatesStep) Run(ctx *Context) error {
+	templatesDir, err := ExportTemplatesSetup(ctx.Logger, ctx.TargetOS, *ctx.Config)
+	if err != nil {
+		return err
+	}
+	ctx.TemplatesDir = templatesDir
+	return nil
+}
+
+// importStep imports the project's resources.
+type importStep struct{}
+
+func (s *importStep) Name() string {
+	return StepImport
+}
+
+func (s *importStep) Dependencies() []string {
+	return []string{StepGodotSetup}
+}
+
+func (s *importStep) Run(ctx *Context) error {
+	return GodotImport(ctx.Logger, ctx.GodotBin, ctx.ProjectDir, ctx.Config.Godot.Engine())
+}
+
+// exportStep exports every configured export preset.
+type exportStep struct{}
+
+func (s *exportStep) Name() string {
+	return StepExport
+}
+
+func (s *exportStep) Dependencies() []string {
+	return []string{StepGodotSetup, StepImport}
+}
+
+func (s *exportStep) Run(ctx *Context) error {
+	return GodotExport(ctx.Logger, ctx.GodotBin, ctx.ProjectDir, ctx.Config.Godot.Engine(), ctx.Config.Export)
+}
@@ -0,0 +1,387 @@
+package steps
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+const (
+	PlanActionResolve  = "resolve"
+	PlanActionDownload = "download"
+	PlanActionInstall  = "install"
+	PlanActionWrite    = "write"
+	PlanActionGodot    = "godot"
+	PlanActionCommand  = "command"
+	PlanActionArtifact = "artifact"
+	PlanActionSkip     = "skip"
+	PlanActionRun      = "run"
+)
+
+// unresolvedGodotBin stands in for the Godot binary in plans where the version isn't resolved yet.
+const unresolvedGodotBin = "<godot>"
+
+// DryRunner is implemented by steps that can describe what they would do without doing it.
+type DryRunner interface {
+	// DryRun returns the actions the step would take, updating the context like Run would
+	// but without touching the network or writing to the filesystem.
+	DryRun(ctx *Context) ([]PlanAction, error)
+}
+
+// PlanAction is a single thing a step would do.
+type PlanAction struct {
+	Kind    string `json:"kind"`
+	Summary string `json:"summary"`
+	// Step is the built-in step taking the action, when a pipeline step runs several
+	Step    string            `json:"step,omitempty"`
+	URLs    []string          `json:"urls,omitempty"`
+	Path    string            `json:"path,omitempty"`
+	Command []string          `json:"command,omitempty"`
+	Dir     string            `json:"dir,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+// PlannedStep is a step of a build plan along with the actions it would take.
+type PlannedStep struct {
+	Name    string       `json:"name"`
+	Actions []PlanAction `json:"actions"`
+}
+
+// GodotPlan is the Godot build a plan would install.
+type GodotPlan struct {
+	Version string `json:"version"`
+	Release string `json:"release"`
+	Mono    bool   `json:"mono"`
+	OS      string `json:"os"`
+	Arch    string `json:"arch"`
+	// Resolved is false when the version is a constraint that needs the network to resolve
+	Resolved     bool   `json:"resolved"`
+	Binary       string `json:"binary,omitempty"`
+	TemplatesDir string `json:"templates_dir,omitempty"`
+}
+
+// BuildPlan is everything a build would do, as reported by a dry run.
+type BuildPlan struct {
+	Godot GodotPlan     `json:"godot"`
+	Steps []PlannedStep `json:"steps"`
+}
+
+// DryRun plans the given steps without running them. It returns a *BuildError if a step
+// can't be planned, such as when the config is invalid.
+func DryRun(ctx *Context, plan []Step) (*BuildPlan, error) {
+	ctx.start(plan)
+
+	buildPlan := &BuildPlan{Steps: make([]PlannedStep, 0, len(plan))}
+	for _, step := range plan {
+		actions, err := dryRunStep(ctx, step)
+		if err != nil {
+			stepErr := asStepError(step, err)
+			ctx.Logger.Errorf("Failed to plan step %s (%s): %s", stepErr.Step, stepErr.Kind, stepErr.Err)
+			return nil, &BuildError{Errors: []*StepError{stepErr}}
+		}
+		buildPlan.Steps = append(buildPlan.Steps, PlannedStep{Name: step.Name(), Actions: actions})
+	}
+
+	godot := ctx.Config.Godot
+	buildPlan.Godot = GodotPlan{
+		Version:      godot.Version,
+		Release:      godot.Release,
+		Mono:         godot.Mono,
+		OS:           ctx.TargetOS.String(),
+		Arch:         godot.TargetArch().String(),
+		Resolved:     !internal.NeedsVersionResolution(godot.Engine()),
+		Binary:       ctx.GodotBin,
+		TemplatesDir: ctx.TemplatesDir,
+	}
+	if buildPlan.Godot.Binary == unresolvedGodotBin {
+		buildPlan.Godot.Binary = ""
+	}
+	return buildPlan, nil
+}
+
+// dryRunStep plans a single step, recording that it completed.
+func dryRunStep(ctx *Context, step Step) ([]PlanAction, error) {
+	var actions []PlanAction
+	if dryRunner, ok := step.(DryRunner); ok {
+		var err error
+		actions, err = dryRunner.DryRun(ctx)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		actions = []PlanAction{{Kind: PlanActionRun, Summary: fmt.Sprintf("run %s, it can't describe its actions", step.Name())}}
+	}
+
+	ctx.completed[step] = true
+	return actions, nil
+}
+
+// Log writes the plan to the logger in a human readable form.
+func (p *BuildPlan) Log(logger logging.Logger) {
+	logger.Infof("Dry run, nothing will be downloaded, installed or run")
+	if p.Godot.Resolved {
+		logger.Infof("Godot %s-%s for %s %s", p.Godot.Version, p.Godot.Release, p.Godot.OS, p.Godot.Arch)
+	} else {
+		logger.Infof("Godot %s (%s) for %s %s, resolved when the build runs", p.Godot.Version, p.Godot.Release, p.Godot.OS, p.Godot.Arch)
+	}
+
+	for _, step := range p.Steps {
+		logger.Infof("Step %s:", step.Name)
+		if len(step.Actions) == 0 {
+			logger.Infof("  nothing to do")
+		}
+		for _, action := range step.Actions {
+			if action.Step != "" {
+				logger.Infof("  [%s] %s: %s", action.Step, action.Kind, action.Summary)
+			} else {
+				logger.Infof("  %s: %s", action.Kind, action.Summary)
+			}
+			for _, url := range action.URLs {
+				logger.Infof("      url: %s", url)
+			}
+			if action.Path != "" {
+				logger.Infof("      path: %s", action.Path)
+			}
+			if len(action.Command) > 0 {
+				logger.Infof("      command: %s", formatCommand(action.Command))
+			}
+			if action.Dir != "" {
+				logger.Infof("      dir: %s", action.Dir)
+			}
+			var names []string = make([]string, 0, len(action.Env))
+			for name := range action.Env {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				logger.Infof("      env: %s=%s", name, action.Env[name])
+			}
+		}
+	}
+}
+
+// WriteJSON writes the plan as JSON to the given path, or to stdout if the path is "-".
+func (p *BuildPlan) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %s", err)
+	}
+	data = append(data, '\n')
+
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write plan: %s", err)
+	}
+	return nil
+}
+
+// formatCommand formats a command line for display, quoting arguments that need it.
+func formatCommand(command []string) string {
+	var args []string = make([]string, 0, len(command))
+	for _, arg := range command {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$`") {
+			arg = strconv.Quote(arg)
+		}
+		args = append(args, arg)
+	}
+	return strings.Join(args, " ")
+}
+
+// downloadAction describes the download of a release file from the download sources.
+func downloadAction(ctx *Context, fileName string, urls []string) PlanAction {
+	if ctx.Config.Download.Offline {
+		return PlanAction{Kind: PlanActionDownload, Summary: fmt.Sprintf("%s from the vendor directory or cache, offline", fileName)}
+	}
+	return PlanAction{Kind: PlanActionDownload, Summary: fileName, URLs: urls}
+}
+
+// customPackageAction describes fetching a custom package.
+func customPackageAction(description string, source string) PlanAction {
+	location, _ := internal.ParsePackageLocation(source)
+	if location.Kind == internal.PackageLocationURL {
+		return PlanAction{Kind: PlanActionDownload, Summary: description, URLs: []string{location.Location}}
+	}
+	return PlanAction{Kind: PlanActionInstall, Summary: description + " from a local package", Path: location.Location}
+}
+
+func (s *godotResolveStep) DryRun(ctx *Context) ([]PlanAction, error) {
+	config := ctx.Config
+	if config.Godot.Source != "" {
+		return []PlanAction{{Kind: PlanActionResolve, Summary: fmt.Sprintf("custom Godot build %s", config.Godot.Engine())}}, nil
+	}
+
+	lock, err := internal.LoadLockfile(internal.LockFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, stepErrorf(ErrorKindConfig, "failed to load lockfile: %s", err)
+	}
+	if lock != nil && lock.Matches(config.Godot, ctx.TargetOS, config.Godot.TargetArch()) {
+		pinLockfile(ctx.Logger, ctx.TargetOS, config.Godot.TargetArch(), config, lock)
+		return []PlanAction{{Kind: PlanActionResolve, Summary: fmt.Sprintf("Godot %s from %s", lock.Engine(), internal.LockFile)}}, nil
+	}
+	if ctx.Frozen {
+		return nil, frozenLockfileError(lock, ctx.TargetOS, config.Godot.TargetArch())
+	}
+
+	var actions []PlanAction
+	engine := config.Godot.Engine()
+	if internal.NeedsVersionResolution(engine) {
+		// Resolving against the download sources needs the network, so the plan stops at the constraint
+		if config.Godot.Manifest == "" {
+			return []PlanAction{
+				{Kind: PlanActionResolve, Summary: fmt.Sprintf("Godot %s (%s) from the download sources' release listings", engine.Version, engine.Release)},
+				{Kind: PlanActionWrite, Summary: "lockfile", Path: internal.LockFile},
+			}, nil
+		}
+
+		manifest, err := internal.LoadVersionManifest(config.Godot.Manifest)
+		if err != nil {
+			return nil, stepErrorf(ErrorKindConfig, "failed to load version manifest: %s", err)
+		}
+		resolved, err := internal.ResolveEngine(manifest, engine)
+		if err != nil {
+			return nil, stepErrorf(ErrorKindConfig, "failed to resolve Godot version: %s", err)
+		}
+
+		actions = append(actions, PlanAction{
+			Kind:    PlanActionResolve,
+			Summary: fmt.Sprintf("Godot %s (%s) to %s from %s", engine.Version, engine.Release, resolved, config.Godot.Manifest),
+		})
+		engine = resolved
+	} else {
+		actions = append(actions, PlanAction{Kind: PlanActionResolve, Summary: fmt.Sprintf("Godot %s", engine)})
+	}
+
+	config.Godot.Version = engine.Version
+	config.Godot.Release = engine.Release
+	return append(actions, PlanAction{Kind: PlanActionWrite, Summary: "lockfile", Path: internal.LockFile}), nil
+}
+
+func (s *godotSetupStep) DryRun(ctx *Context) ([]PlanAction, error) {
+	godot := ctx.Config.Godot
+	engine := godot.EditorEngine()
+	arch := godot.TargetArch()
+	if internal.NeedsVersionResolution(engine) {
+		// The install path depends on the resolved version, so later steps get a placeholder
+		ctx.GodotBin = unresolvedGodotBin
+		return []PlanAction{{Kind: PlanActionDownload, Summary: fmt.Sprintf("Godot %s (%s) once its version is resolved", engine.Version, engine.Release)}}, nil
+	}
+
+	engines := internal.NewEngineManager(ctx.TargetOS, ctx.Logger, &internal.EngineManagerOptions{})
+
+	var actions []PlanAction
+	if godot.Source != "" {
+		actions = append(actions, customPackageAction("custom Godot build", godot.Source))
+	} else {
+		installed, err := engines.Get(engine)
+		if err == nil && (godot.Checksum == "" || strings.EqualFold(installed.SHA512, godot.Checksum)) {
+			ctx.GodotBin = installed.Binary
+			actions = append(actions, PlanAction{Kind: PlanActionInstall, Summary: fmt.Sprintf("Godot %s is already installed", engine), Path: installed.Binary})
+			return append(actions, verifyVersionActions(ctx.GodotBin, godot)...), nil
+		}
+
+		downloader := internal.NewDownloader(ctx.TargetOS, ctx.Logger, ctx.Config.DownloaderOptions())
+		fileName, urls, err := downloader.GodotPackageURLs(ctx.TargetOS, arch, engine)
+		if err != nil {
+			return nil, stepErrorf(ErrorKindConfig, "failed to find Godot package: %s", err)
+		}
+		actions = append(actions, downloadAction(ctx, fileName, urls))
+	}
+
+	binary, err := internal.GodotBinaryName(ctx.TargetOS, arch, engine)
+	if err != nil {
+		return nil, stepErrorf(ErrorKindConfig, "failed to find Godot binary: %s", err)
+	}
+	if godot.Binary != "" {
+		// Mono builds are installed from the binary's directory, so only its name is kept
+		binary = filepath.FromSlash(godot.Binary)
+		if engine.Mono && ctx.TargetOS != internal.TargetOSMacOS {
+			binary = filepath.Base(binary)
+		}
+	}
+	ctx.GodotBin = filepath.Join(engines.EngineDir(engine), binary)
+	actions = append(actions, PlanAction{Kind: PlanActionInstall, Summary: fmt.Sprintf("Godot %s", engine), Path: ctx.GodotBin})
+
+	return append(actions, verifyVersionActions(ctx.GodotBin, godot)...), nil
+}
+
+// verifyVersionActions describes checking the version of the Godot binary, if the config asks for it.
+func verifyVersionActions(godotBin string, godot internal.BuildConfigGodot) []PlanAction {
+	if !godot.VerifyVersion {
+		return nil
+	}
+	return []PlanAction{{Kind: PlanActionGodot, Summary: fmt.Sprintf("verify the version is %s", godot.Engine()), Command: []string{godotBin, "--version"}}}
+}
+
+func (s *exportTemplatesStep) DryRun(ctx *Context) ([]PlanAction, error) {
+	godot := ctx.Config.Godot
+	engine := godot.Engine()
+	if internal.NeedsVersionResolution(engine) {
+		return []PlanAction{{Kind: PlanActionDownload, Summary: fmt.Sprintf("export templates for Godot %s (%s) once its version is resolved", engine.Version, engine.Release)}}, nil
+	}
+
+	var actions []PlanAction
+	if godot.TemplatesSource != "" {
+		actions = append(actions, customPackageAction("custom export templates", godot.TemplatesSource))
+	} else {
+		downloader := internal.NewDownloader(ctx.TargetOS, ctx.Logger, ctx.Config.DownloaderOptions())
+		fileName, urls, err := downloader.ExportTemplatesURLs(engine)
+		if err != nil {
+			return nil, stepErrorf(ErrorKindConfig, "failed to find export templates package: %s", err)
+		}
+		actions = append(actions, downloadAction(ctx, fileName, urls))
+	}
+
+	ctx.TemplatesDir = internal.ExportTemplatesInstallDir(ctx.TargetOS, engine)
+	return append(actions, PlanAction{Kind: PlanActionInstall, Summary: "export templates", Path: ctx.TemplatesDir}), nil
+}
+
+func (s *importStep) DryRun(ctx *Context) ([]PlanAction, error) {
+	projectDir, err := filepath.Abs(ctx.ProjectDir)
+	if err != nil {
+		return nil, stepErrorf(ErrorKindFailure, "failed to get absolute path of project: %s", err)
+	}
+
+	args := importArgs(projectDir, ctx.Config.Godot.Engine())
+	return []PlanAction{{
+		Kind:    PlanActionGodot,
+		Summary: "import project resources",
+		Command: append([]string{ctx.GodotBin}, args.Args()...),
+		Dir:     projectDir,
+	}}, nil
+}
+
+func (s *exportStep) DryRun(ctx *Context) ([]PlanAction, error) {
+	projectDir, err := filepath.Abs(ctx.ProjectDir)
+	if err != nil {
+		return nil, stepErrorf(ErrorKindFailure, "failed to get absolute path of project: %s", err)
+	}
+
+	var actions []PlanAction = make([]PlanAction, 0, len(ctx.Config.Export)*2)
+	for _, export := range ctx.Config.Export {
+		command, stepErr := newExportCommand(projectDir, ctx.Config.Godot.Engine(), export)
+		if stepErr != nil {
+			return nil, stepErr
+		}
+
+		actions = append(actions,
+			PlanAction{
+				Kind:    PlanActionGodot,
+				Summary: fmt.Sprintf("export %s (%s)", export.Preset, command.exportType),
+				Command: append([]string{ctx.GodotBin}, command.args.Args()...),
+				Dir:     projectDir,
+			},
+			PlanAction{Kind: PlanActionArtifact, Summary: export.Preset, Path: command.outputPath},
+		)
+	}
+	return actions, nil
+}
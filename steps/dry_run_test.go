@@ -0,0 +1,218 @@
+package steps
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+// newDryRunContext creates a context for a project in a temporary directory, with the user's
+// data and cache directories in it too, and a download source that fails the test on any request.
+func newDryRunContext(t *testing.T, godot internal.BuildConfigGodot) (*Context, string, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	t.Setenv("HOME", filepath.Join(root, "home"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(root, "cache"))
+	t.Setenv(internal.CacheDirEnv, "")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request during a dry run: %s %s", r.Method, r.URL)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	projectDir := filepath.Join(root, "project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "project.godot"), []byte("config_version=5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The lockfile is looked up in the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	config := &internal.BuildConfig{
+		Godot:   godot,
+		Sources: []internal.BuildConfigSource{{Type: internal.SourceTypeCustom, Name: "mirror", URL: server.URL + "/{version}-{release}/{file}"}},
+		Export: []internal.BuildConfigExport{
+			{Preset: "Linux/X11", Path: "build/game.x86_64"},
+			{Preset: "Web", Path: "build/web/index.html", Type: "debug"},
+		},
+	}
+
+	return &Context{
+		Logger:     logging.NewLogger(&logging.LoggerOptions{}),
+		TargetOS:   internal.TargetOSLinux,
+		Config:     config,
+		ProjectDir: projectDir,
+	}, root, server.URL
+}
+
+// snapshotFiles returns the path, size and modification time of everything in a directory.
+func snapshotFiles(t *testing.T, root string) []string {
+	t.Helper()
+
+	var files []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		files = append(files, fmt.Sprintf("%s %d %s", path, info.Size(), info.ModTime()))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestDryRunDoesNotDownloadOrWrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		godot internal.BuildConfigGodot
+	}{
+		{"exact version", internal.BuildConfigGodot{Version: "4.2.1", Release: "stable"}},
+		{"two-part version", internal.BuildConfigGodot{Version: "4.3", Release: "stable"}},
+		{"mono", internal.BuildConfigGodot{Version: "4.2.1", Release: "stable", Mono: true}},
+		{"Godot 3", internal.BuildConfigGodot{Version: "3.5.3", Release: "stable"}},
+		{"constraint", internal.BuildConfigGodot{Version: "~4.2", Release: "stable"}},
+		{"latest", internal.BuildConfigGodot{Version: "latest", Release: "latest-rc"}},
+		{"custom build", internal.BuildConfigGodot{Version: "4.2.1", Release: "stable", Source: "url:https://builds.example.invalid/godot.zip", Checksum: strings.Repeat("0", 128)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, root, _ := newDryRunContext(t, test.godot)
+			before := snapshotFiles(t, root)
+
+			plan, err := DefaultRegistry().Plan([]string{StepExport, StepExportTemplates})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := DryRun(ctx, plan); err != nil {
+				t.Fatalf("dry run failed: %s", err)
+			}
+
+			if after := snapshotFiles(t, root); !reflect.DeepEqual(before, after) {
+				t.Errorf("expected the dry run not to write any files, before:\n%s\nafter:\n%s", strings.Join(before, "\n"), strings.Join(after, "\n"))
+			}
+		})
+	}
+}
+
+func TestDryRunPlanJSON(t *testing.T) {
+	ctx, root, serverURL := newDryRunContext(t, internal.BuildConfigGodot{Version: "4.2.1", Release: "stable", Arch: "x86_64"})
+
+	plan, err := DefaultRegistry().Plan([]string{StepExport, StepExportTemplates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buildPlan, err := DryRun(ctx, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+	if err := buildPlan.WriteJSON(planPath); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(planPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Godot map[string]interface{} `json:"godot"`
+		Steps []struct {
+			Name    string                   `json:"name"`
+			Actions []map[string]interface{} `json:"actions"`
+		} `json:"steps"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to decode plan: %s\n%s", err, data)
+	}
+
+	godotBin := filepath.Join(root, "data", "gbt", "engines", "4.2.1-stable", "Godot_v4.2.1-stable_linux.x86_64")
+	templatesDir := filepath.Join(root, "data", "godot", "export_templates", "4.2.1.stable")
+	expectedGodot := map[string]interface{}{
+		"version":       "4.2.1",
+		"release":       "stable",
+		"mono":          false,
+		"os":            "linux",
+		"arch":          "x86_64",
+		"resolved":      true,
+		"binary":        godotBin,
+		"templates_dir": templatesDir,
+	}
+	if !reflect.DeepEqual(decoded.Godot, expectedGodot) {
+		t.Errorf("expected godot %v, got %v", expectedGodot, decoded.Godot)
+	}
+
+	var steps []string
+	for _, step := range decoded.Steps {
+		steps = append(steps, step.Name)
+	}
+	expectedSteps := []string{StepGodotResolve, StepGodotSetup, StepExportTemplates, StepImport, StepExport}
+	if !reflect.DeepEqual(steps, expectedSteps) {
+		t.Fatalf("expected steps %v, got %v", expectedSteps, steps)
+	}
+
+	setup := decoded.Steps[1].Actions
+	if len(setup) != 2 || setup[0]["kind"] != PlanActionDownload || setup[1]["kind"] != PlanActionInstall {
+		t.Fatalf("unexpected godot-setup actions: %v", setup)
+	}
+	expectedURLs := []interface{}{serverURL + "/4.2.1-stable/Godot_v4.2.1-stable_linux.x86_64.zip"}
+	if !reflect.DeepEqual(setup[0]["urls"], expectedURLs) || setup[1]["path"] != godotBin {
+		t.Errorf("unexpected godot-setup actions: %v", setup)
+	}
+
+	export := decoded.Steps[4].Actions
+	if len(export) != 4 {
+		t.Fatalf("expected a command and an artifact for each export, got %v", export)
+	}
+	command, ok := export[0]["command"].([]interface{})
+	if export[0]["kind"] != PlanActionGodot || !ok || len(command) == 0 || command[0] != godotBin || export[0]["dir"] != ctx.ProjectDir {
+		t.Errorf("unexpected export command: %v", export[0])
+	}
+	if export[1]["kind"] != PlanActionArtifact || export[1]["summary"] != "Linux/X11" || export[1]["path"] == "" {
+		t.Errorf("unexpected export artifact: %v", export[1])
+	}
+	for _, action := range export {
+		for key := range action {
+			if !containsKey([]string{"kind", "summary", "step", "urls", "path", "command", "dir", "env"}, key) {
+				t.Errorf("unexpected key %q in action %v", key, action)
+			}
+		}
+	}
+}
+
+// containsKey returns true if the key is in the list.
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package steps
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+)
+
+// ErrorKind is the cause of a step failure, which decides the exit code of the build.
+type ErrorKind uint8
+
+const (
+	ErrorKindFailure ErrorKind = iota
+	ErrorKindConfig
+	ErrorKindNetwork
+	ErrorKindGodot
+	ErrorKindValidation
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorKindConfig:
+		return "config error"
+	case ErrorKindNetwork:
+		return "network error"
+	case ErrorKindGodot:
+		return "Godot error"
+	case ErrorKindValidation:
+		return "validation error"
+	}
+	return "error"
+}
+
+// ExitCode returns the exit code of a build that failed with this kind of error.
+func (k ErrorKind) ExitCode() int {
+	switch k {
+	case ErrorKindConfig:
+		return internal.ExitCodeConfig
+	case ErrorKindNetwork:
+		return internal.ExitCodeNetwork
+	case ErrorKindGodot:
+		return internal.ExitCodeGodot
+	case ErrorKindValidation:
+		return internal.ExitCodeValidation
+	}
+	return internal.ExitCodeFailure
+}
+
+// StepError is the failure of a step.
+type StepError struct {
+	Kind ErrorKind
+	// Step is the name of the failed step, filled in by the runner
+	Step string
+	Err  error
+}
+
+// stepErrorf creates a step error of the given kind.
+func stepErrorf(kind ErrorKind, format string, args ...interface{}) *StepError {
+	return &StepError{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *StepError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// downloadErrorKind returns the kind of a failed download. A checksum mismatch is a validation
+// error, and only failures of the network or the server are network errors.
+func downloadErrorKind(err error) ErrorKind {
+	var checksumErr *internal.ChecksumError
+	if errors.As(err, &checksumErr) {
+		return ErrorKindValidation
+	}
+	var networkErr *internal.NetworkError
+	if errors.As(err, &networkErr) {
+		return ErrorKindNetwork
+	}
+	return ErrorKindFailure
+}
+
+// resolveErrorKind returns the kind of a failed version resolution. A version that can't be
+// resolved is a config error, and listing the releases can fail like any other download.
+func resolveErrorKind(err error) ErrorKind {
+	var versionErr *internal.UnresolvableVersionError
+	if errors.As(err, &versionErr) {
+		return ErrorKindConfig
+	}
+	return downloadErrorKind(err)
+}
+
+// BuildError holds every step failure of a build. Failures of steps that continue on error
+// are included, but don't fail the build on their own.
+type BuildError struct {
+	Errors []*StepError
+}
+
+func (e *BuildError) Error() string {
+	var messages []string = make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", err.Step, err.Err))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ExitCode returns the exit code for the last failure, the one that stopped the build.
+func (e *BuildError) ExitCode() int {
+	if len(e.Errors) == 0 {
+		return internal.ExitCodeFailure
+	}
+	return e.Errors[len(e.Errors)-1].Kind.ExitCode()
+}
@@ -0,0 +1,37 @@
+package steps
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+)
+
+func TestErrorKinds(t *testing.T) {
+	checksumErr := &internal.ChecksumError{FileName: "Godot_v4.2.1-stable_linux.x86_64.zip", Expected: "aa", Actual: "bb"}
+	networkErr := &internal.NetworkError{Err: errors.New("connection refused")}
+	versionErr := &internal.UnresolvableVersionError{Version: "3.x", Release: "stable"}
+
+	tests := []struct {
+		name     string
+		err      error
+		download ErrorKind
+		resolve  ErrorKind
+	}{
+		{"checksum", fmt.Errorf("failed to download: %w", checksumErr), ErrorKindValidation, ErrorKindValidation},
+		{"network", fmt.Errorf("failed to download: %w", networkErr), ErrorKindNetwork, ErrorKindNetwork},
+		{"checksum from one source", fmt.Errorf("every source failed:\n%w", errors.Join(networkErr, checksumErr)), ErrorKindValidation, ErrorKindValidation},
+		{"unresolvable version", versionErr, ErrorKindFailure, ErrorKindConfig},
+		{"other", errors.New("failed to move package into cache"), ErrorKindFailure, ErrorKindFailure},
+	}
+
+	for _, test := range tests {
+		if kind := downloadErrorKind(test.err); kind != test.download {
+			t.Errorf("%s: expected download error kind %s, got %s", test.name, test.download, kind)
+		}
+		if kind := resolveErrorKind(test.err); kind != test.resolve {
+			t.Errorf("%s: expected resolve error kind %s, got %s", test.name, test.resolve, kind)
+		}
+	}
+}
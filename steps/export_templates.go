@@ -0,0 +1,41 @@
+package steps
+
+import (
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+// ExportTemplatesSetup downloads and installs the export templates matching the configured Godot version.
+func ExportTemplatesSetup(logger logging.Logger, targetOS internal.TargetOS, config internal.BuildConfig) (string, error) {
+	logger.StartGroup("Export Templates Setup")
+	defer logger.EndGroup()
+	downloader := internal.NewDownloader(targetOS, logger, config.DownloaderOptions())
+	godot := config.Godot
+
+	var templatesPackage string
+	var err error
+	if godot.TemplatesSource != "" {
+		location, _ := internal.ParsePackageLocation(godot.TemplatesSource)
+		logger.Infof("Fetching custom export templates from %s", location)
+		templatesPackage, err = downloader.FetchCustomPackage(targetOS, godot.Engine(), location, godot.TemplatesChecksum, godot.SourceHTTPHeaders())
+		if err != nil {
+			return "", stepErrorf(downloadErrorKind(err), "failed to fetch custom export templates: %s", err)
+		}
+	} else {
+		logger.Infof("Downloading export templates")
+		templatesPackage, err = downloader.DownloadExportTemplates(targetOS, godot.Engine(), godot.TemplatesChecksum)
+		if err != nil {
+			return "", stepErrorf(downloadErrorKind(err), "failed to download export templates: %s", err)
+		}
+	}
+	logger.Infof("Export templates package: %s", templatesPackage)
+
+	logger.Infof("Installing export templates")
+	templatesDir, err := downloader.InstallExportTemplates(templatesPackage, targetOS, godot.Engine())
+	if err != nil {
+		return "", stepErrorf(ErrorKindFailure, "failed to install export templates: %s", err)
+	}
+	logger.Infof("Export templates: %s", templatesDir)
+
+	return templatesDir, nil
+}
@@ -0,0 +1,133 @@
+package steps
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+// GodotExport exports the project in projectDir once for each export listed in the build config.
+// A failed export doesn't stop the others, every failure is reported at the end.
+func GodotExport(logger logging.Logger, godotBin string, projectDir string, engine internal.GodotEngine, exports []internal.BuildConfigExport) error {
+	logger.StartGroup("Godot Export")
+	defer logger.EndGroup()
+
+	if len(exports) == 0 {
+		logger.Warnf("No exports configured, add an [[export]] entry to %s", internal.BuildConfigFile)
+		return nil
+	}
+
+	projectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return stepErrorf(ErrorKindFailure, "failed to get absolute path of project: %s", err)
+	}
+
+	var failures []*StepError
+	for _, export := range exports {
+		if err := godotExportPreset(logger, godotBin, projectDir, engine, export); err != nil {
+			if len(exports) > 1 {
+				logger.Errorf("Export of %s failed: %s", export.Preset, err)
+			}
+			failures = append(failures, err)
+		}
+	}
+
+	switch len(failures) {
+	case 0:
+		return nil
+	case 1:
+		return failures[0]
+	}
+
+	// The first failure decides the exit code
+	var messages []string = make([]string, 0, len(failures))
+	for _, failure := range failures {
+		messages = append(messages, failure.Error())
+	}
+	return stepErrorf(failures[0].Kind, "%d of %d exports failed: %s", len(failures), len(exports), strings.Join(messages, "; "))
+}
+
+// exportCommand is a single export, ready to be run.
+type exportCommand struct {
+	args       internal.GodotArgBuilder
+	exportType internal.ExportType
+	outputPath string
+	timeout    time.Duration
+}
+
+// newExportCommand validates an export from the build config and builds its Godot arguments.
+func newExportCommand(projectDir string, engine internal.GodotEngine, export internal.BuildConfigExport) (*exportCommand, *StepError) {
+	if export.Preset == "" || export.Path == "" {
+		return nil, stepErrorf(ErrorKindConfig, "exports must have both a preset and a path")
+	}
+
+	exportType, err := internal.ParseExportType(export.Type)
+	if err != nil {
+		return nil, stepErrorf(ErrorKindConfig, "invalid export type for preset %s: %s", export.Preset, err)
+	}
+
+	var timeout time.Duration
+	if export.Timeout != "" {
+		timeout, err = time.ParseDuration(export.Timeout)
+		if err != nil {
+			return nil, stepErrorf(ErrorKindConfig, "invalid export timeout for preset %s: %s", export.Preset, err)
+		}
+	}
+
+	outputPath := export.Path
+	if !filepath.IsAbs(outputPath) {
+		outputPath = filepath.Join(projectDir, outputPath)
+	}
+
+	args := internal.NewGodotArgBuilder(projectDir, engine.Version)
+	args.AddHeadlessFlag()
+	args.AddExportFlag(exportType, export.Preset, outputPath)
+
+	return &exportCommand{
+		args:       args,
+		exportType: exportType,
+		outputPath: outputPath,
+		timeout:    timeout,
+	}, nil
+}
+
+// godotExportPreset runs a single export and checks that it produced an output file.
+func godotExportPreset(logger logging.Logger, godotBin string, projectDir string, engine internal.GodotEngine, export internal.BuildConfigExport) *StepError {
+	command, stepErr := newExportCommand(projectDir, engine, export)
+	if stepErr != nil {
+		return stepErr
+	}
+	outputPath := command.outputPath
+
+	// Godot won't create missing directories, and a stale file would hide a failed export
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return stepErrorf(ErrorKindFailure, "failed to create output directory: %s", err)
+	}
+	if err := os.Remove(outputPath); err != nil && !os.IsNotExist(err) {
+		return stepErrorf(ErrorKindFailure, "failed to remove previous export: %s", err)
+	}
+
+	runner := internal.NewGodotRunner(godotBin, logger, &internal.GodotRunnerOptions{
+		Timeout: command.timeout,
+		Dir:     projectDir,
+	})
+
+	logger.Infof("Exporting %s (%s) to %s", export.Preset, command.exportType, outputPath)
+	result, err := runner.Run(context.Background(), command.args.Args())
+	if err != nil {
+		return stepErrorf(ErrorKindGodot, "Godot failed to export %s: %s", export.Preset, err)
+	}
+	logger.Debugf("Export of %s took %s", export.Preset, result.Duration)
+
+	if _, err := os.Stat(outputPath); err != nil {
+		return stepErrorf(ErrorKindValidation, "Godot did not produce an output file for %s: %s", export.Preset, err)
+	}
+
+	logger.Infof("Exported %s", outputPath)
+	return nil
+}
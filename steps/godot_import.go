@@ -0,0 +1,50 @@
+package steps
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+// GodotImport imports the resources of the project in projectDir, so exports don't run
+// against a missing or stale .godot import cache.
+func GodotImport(logger logging.Logger, godotBin string, projectDir string, engine internal.GodotEngine) error {
+	logger.StartGroup("Godot Import")
+	defer logger.EndGroup()
+
+	projectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return stepErrorf(ErrorKindFailure, "failed to get absolute path of project: %s", err)
+	}
+
+	args := importArgs(projectDir, engine)
+	runner := internal.NewGodotRunner(godotBin, logger, &internal.GodotRunnerOptions{
+		Dir: projectDir,
+	})
+
+	logger.Infof("Importing project resources")
+	result, err := runner.Run(context.Background(), args.Args())
+	if err != nil {
+		return stepErrorf(ErrorKindGodot, "Godot failed to import the project: %s", err)
+	}
+	logger.Infof("Imported project resources in %s", result.Duration.Round(time.Millisecond))
+
+	return nil
+}
+
+// importArgs returns the arguments that make the given engine import a project and quit.
+func importArgs(projectDir string, engine internal.GodotEngine) internal.GodotArgBuilder {
+	args := internal.NewGodotArgBuilder(projectDir, engine.Version)
+	args.AddHeadlessFlag()
+	if version, err := internal.ParseGodotVersion(engine.Version); err == nil && version.Compare(internal.GodotVersion{Major: 4, Minor: 2}) >= 0 {
+		args.AddImportFlag()
+	} else {
+		// Older versions only import when the editor starts
+		args.AddEditorFlag()
+		args.AddQuitFlag()
+	}
+	return args
+}
@@ -0,0 +1,110 @@
+package steps
+
+import (
+	"os"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+// GodotResolve resolves the configured Godot version constraint and release to a concrete
+// version, updating the config in place so later steps install the resolved version.
+// The result is recorded in .godot-build.lock, and later runs install exactly what the lockfile
+// records for as long as it matches the config. In frozen mode a missing or outdated lockfile
+// is an error instead.
+func GodotResolve(logger logging.Logger, targetOS internal.TargetOS, config *internal.BuildConfig, frozen bool) error {
+	arch := config.Godot.TargetArch()
+
+	// Custom builds have an exact version and aren't part of any release listing
+	if config.Godot.Source != "" {
+		logger.Infof("Using custom Godot build %s-%s", config.Godot.Version, config.Godot.Release)
+		logger.SetOutput("godot-version", config.Godot.Version)
+		logger.SetOutput("godot-release", config.Godot.Release)
+		return nil
+	}
+
+	lock, err := internal.LoadLockfile(internal.LockFile)
+	if err != nil && !os.IsNotExist(err) {
+		return stepErrorf(ErrorKindConfig, "failed to load lockfile: %s", err)
+	}
+
+	if lock != nil && lock.Matches(config.Godot, targetOS, arch) {
+		applyLockfile(logger, targetOS, arch, config, lock)
+		return nil
+	}
+
+	if frozen {
+		return frozenLockfileError(lock, targetOS, arch)
+	}
+
+	downloader := internal.NewDownloader(targetOS, logger, config.DownloaderOptions())
+
+	engine := config.Godot.Engine()
+	if internal.NeedsVersionResolution(engine) {
+		var index internal.VersionIndex = downloader.VersionIndex()
+		if config.Godot.Manifest != "" {
+			manifest, err := internal.LoadVersionManifest(config.Godot.Manifest)
+			if err != nil {
+				return stepErrorf(ErrorKindConfig, "failed to load version manifest: %s", err)
+			}
+			index = manifest
+		}
+
+		resolved, err := internal.ResolveEngine(index, engine)
+		if err != nil {
+			return stepErrorf(resolveErrorKind(err), "failed to resolve Godot version: %s", err)
+		}
+
+		logger.Infof("Resolved Godot %s (%s) to %s-%s", engine.Version, engine.Release, resolved.Version, resolved.Release)
+		engine = resolved
+	}
+
+	lock, err = downloader.GenerateLockfile(config.Godot, engine, targetOS, arch)
+	if err != nil {
+		return stepErrorf(downloadErrorKind(err), "failed to generate lockfile: %s", err)
+	}
+	if err := lock.Write(internal.LockFile); err != nil {
+		return stepErrorf(ErrorKindFailure, "failed to write lockfile: %s", err)
+	}
+	logger.Infof("Wrote %s", internal.LockFile)
+
+	applyLockfile(logger, targetOS, arch, config, lock)
+	return nil
+}
+
+// frozenLockfileError returns the error for a missing or outdated lockfile in frozen mode.
+func frozenLockfileError(lock *internal.Lockfile, targetOS internal.TargetOS, arch internal.TargetArch) error {
+	if lock == nil {
+		return stepErrorf(ErrorKindValidation, "%s not found, run without --frozen to create it", internal.LockFile)
+	}
+	if _, ok := lock.Package(internal.LockPackageEditor, targetOS, arch); !ok {
+		return stepErrorf(ErrorKindValidation, "%s has no Godot package for %s %s, run without --frozen to update it", internal.LockFile, targetOS, arch)
+	}
+	return stepErrorf(ErrorKindValidation, "%s is out of date with %s, run without --frozen to update it", internal.LockFile, internal.BuildConfigFile)
+}
+
+// applyLockfile pins the config to the version and checksums recorded in the lockfile, and
+// outputs the pinned version.
+func applyLockfile(logger logging.Logger, targetOS internal.TargetOS, arch internal.TargetArch, config *internal.BuildConfig, lock *internal.Lockfile) {
+	logger.Infof("Using Godot %s from %s", lock.Engine(), internal.LockFile)
+	pinLockfile(logger, targetOS, arch, config, lock)
+
+	logger.SetOutput("godot-version", config.Godot.Version)
+	logger.SetOutput("godot-release", config.Godot.Release)
+}
+
+// pinLockfile pins the config to the version and checksums recorded in the lockfile.
+func pinLockfile(logger logging.Logger, targetOS internal.TargetOS, arch internal.TargetArch, config *internal.BuildConfig, lock *internal.Lockfile) {
+	engine := lock.Engine()
+	config.Godot.Version = engine.Version
+	config.Godot.Release = engine.Release
+
+	if pkg, ok := lock.Package(internal.LockPackageEditor, targetOS, arch); ok {
+		config.Godot.Checksum = pkg.SHA512
+	} else {
+		logger.Warnf("%s has no Godot package for %s %s", internal.LockFile, targetOS, arch)
+	}
+	if pkg, ok := lock.Package(internal.LockPackageTemplates, targetOS, arch); ok {
+		config.Godot.TemplatesChecksum = pkg.SHA512
+	}
+}
@@ -1,33 +1,85 @@
 package steps
 
 import (
-	"os"
+	"strings"
 
 	"github.com/yeslayla/godot-build-tools/internal"
 	"github.com/yeslayla/godot-build-tools/logging"
 )
 
-func GodotSetup(logger logging.Logger, targetOS internal.TargetOS, version string, release string) (string, bool) {
+func GodotSetup(logger logging.Logger, targetOS internal.TargetOS, config internal.BuildConfig) (string, error) {
 	logger.StartGroup("Godot Setup")
 	defer logger.EndGroup()
-	downloader := internal.NewDownloader(internal.TargetOSLinux, logger, &internal.DownloaderOptions{})
+	downloader := internal.NewDownloader(targetOS, logger, config.DownloaderOptions())
+	godot := config.Godot
 
-	logger.Infof("Downloading Godot")
-	godotPackage, err := downloader.DownloadGodot(internal.TargetOSLinux, version, release)
-	if err != nil {
-		logger.Errorf("Failed to download Godot: %s", err)
-		return "", false
+	// Engines are installed side by side, so a matching install can be reused as is
+	engines := internal.NewEngineManager(targetOS, logger, &internal.EngineManagerOptions{})
+	installed, installedErr := engines.Get(godot.EditorEngine())
+
+	var godotPackage string
+	var err error
+	if godot.Source != "" {
+		// Custom builds can be rebuilt under the same version, so compare the packages instead
+		location, _ := internal.ParsePackageLocation(godot.Source)
+		logger.Infof("Fetching custom Godot build from %s", location)
+		godotPackage, err = downloader.FetchCustomPackage(targetOS, godot.EditorEngine(), location, godot.Checksum, godot.SourceHTTPHeaders())
+		if err != nil {
+			return "", stepErrorf(downloadErrorKind(err), "failed to fetch custom Godot build: %s", err)
+		}
+
+		checksum, err := internal.FileSHA512(godotPackage)
+		if err != nil {
+			return "", stepErrorf(ErrorKindFailure, "failed to hash custom Godot build: %s", err)
+		}
+		if installedErr == nil && strings.EqualFold(installed.SHA512, checksum) {
+			logger.Infof("Godot %s is already installed", installed.Engine)
+			logger.Infof("Godot binary: %s", installed.Binary)
+			return installed.Binary, verifyGodotVersion(logger, installed.Binary, godot)
+		}
+	} else {
+		if installedErr == nil {
+			if godot.Checksum == "" || strings.EqualFold(installed.SHA512, godot.Checksum) {
+				logger.Infof("Godot %s is already installed", installed.Engine)
+				logger.Infof("Godot binary: %s", installed.Binary)
+				return installed.Binary, verifyGodotVersion(logger, installed.Binary, godot)
+			}
+			logger.Infof("Reinstalling Godot %s, it doesn't match the expected checksum", installed.Engine)
+		}
+
+		logger.Infof("Downloading Godot")
+		godotPackage, err = downloader.DownloadGodot(targetOS, godot.TargetArch(), godot.Engine(), godot.Checksum)
+		if err != nil {
+			return "", stepErrorf(downloadErrorKind(err), "failed to download Godot: %s", err)
+		}
 	}
-	defer os.Remove(godotPackage)
 	logger.Infof("Godot package: %s", godotPackage)
 
 	logger.Infof("Installing Godot")
-	godotBin, err := downloader.InstallGodot(godotPackage, internal.TargetOSLinux, version, release)
+	godotBin, err := downloader.InstallGodot(godotPackage, targetOS, godot.TargetArch(), godot.EditorEngine(), godot.Binary)
 	if err != nil {
-		logger.Errorf("Failed to install Godot: %s", err)
-		return "", false
+		return "", stepErrorf(ErrorKindFailure, "failed to install Godot: %s", err)
 	}
 	logger.Infof("Godot binary: %s", godotBin)
 
-	return godotBin, true
+	return godotBin, verifyGodotVersion(logger, godotBin, godot)
+}
+
+// verifyGodotVersion checks the version reported by the Godot binary against the config,
+// if the config asks for it.
+func verifyGodotVersion(logger logging.Logger, godotBin string, godot internal.BuildConfigGodot) error {
+	if !godot.VerifyVersion {
+		return nil
+	}
+
+	reported, err := internal.GodotBinaryVersion(godotBin)
+	if err != nil {
+		return stepErrorf(ErrorKindGodot, "failed to verify Godot version: %s", err)
+	}
+	if !godot.Engine().MatchesVersionString(reported) {
+		return stepErrorf(ErrorKindValidation, "Godot binary reports version %s, expected %s", reported, godot.Engine())
+	}
+
+	logger.Infof("Verified Godot version %s", reported)
+	return nil
 }
@@ -0,0 +1,40 @@
+package steps
+
+import (
+	"strings"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+// OfflineCheck makes sure every package the requested steps need is vendored or cached,
+// listing the missing ones so they can be fetched on a connected machine.
+func OfflineCheck(logger logging.Logger, targetOS internal.TargetOS, config internal.BuildConfig, setup bool, templates bool) error {
+	godot := config.Godot
+	downloader := internal.NewDownloader(targetOS, logger, config.DownloaderOptions())
+
+	// An installed release is reused as is by the setup step, custom builds are always compared to their package
+	if setup && godot.Source == "" {
+		engines := internal.NewEngineManager(targetOS, logger, &internal.EngineManagerOptions{})
+		if installed, err := engines.Get(godot.Engine()); err == nil {
+			setup = godot.Checksum != "" && !strings.EqualFold(installed.SHA512, godot.Checksum)
+		}
+	}
+
+	artifacts, err := internal.BuildArtifacts(godot, targetOS, godot.TargetArch(), setup, templates)
+	if err != nil {
+		return stepErrorf(ErrorKindConfig, "failed to list required packages: %s", err)
+	}
+
+	missing := downloader.MissingArtifacts(targetOS, artifacts)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	var names []string = make([]string, 0, len(missing))
+	for _, artifact := range missing {
+		logger.Errorf("Missing %s (Godot %s)", artifact.FileName, artifact.Engine)
+		names = append(names, artifact.FileName)
+	}
+	return stepErrorf(ErrorKindNetwork, "missing %d package(s) needed offline, run `gbt fetch` on a connected machine: %s", len(missing), strings.Join(names, ", "))
+}
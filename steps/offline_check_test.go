@@ -0,0 +1,39 @@
+package steps
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+func TestOfflineCheckListsMissingPackages(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv(internal.CacheDirEnv, "")
+
+	config := internal.BuildConfig{
+		Godot:    internal.BuildConfigGodot{Version: "4.3", Release: "stable"},
+		Cache:    internal.BuildConfigCache{Dir: filepath.Join(dir, "cache")},
+		Download: internal.BuildConfigDownload{Offline: true, VendorDir: filepath.Join(dir, "vendor")},
+	}
+	logger := logging.NewLogger(&logging.LoggerOptions{})
+
+	err := OfflineCheck(logger, internal.TargetOSLinux, config, true, true)
+	var stepErr *StepError
+	if !errors.As(err, &stepErr) || stepErr.Kind != ErrorKindNetwork {
+		t.Fatalf("expected a network step error, got %v", err)
+	}
+	for _, expected := range []string{"missing 2 package(s)", "gbt fetch", "Godot_v4.3-stable_linux.", "Godot_v4.3-stable_export_templates.tpz"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %q, got %q", expected, err)
+		}
+	}
+
+	if err := OfflineCheck(logger, internal.TargetOSLinux, config, false, false); err != nil {
+		t.Errorf("expected no packages to be needed, got %s", err)
+	}
+}
@@ -0,0 +1,262 @@
+package steps
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+)
+
+// PlanPipeline returns the steps of a pipeline declared in the build config, in the order
+// they are declared. Steps that use a built-in step run it along with its dependencies,
+// skipping any that already ran earlier in the pipeline.
+func (r *Registry) PlanPipeline(pipeline internal.BuildConfigPipeline) ([]Step, error) {
+	var plan []Step = make([]Step, 0, len(pipeline.Steps))
+	for i, config := range pipeline.Steps {
+		condition, err := internal.ParseCondition(config.If)
+		if err != nil {
+			return nil, fmt.Errorf("step %d of %s: %s", i+1, pipeline.Name, err)
+		}
+
+		var inner Step
+		if config.Uses != "" {
+			steps, err := r.Plan([]string{config.Uses})
+			if err != nil {
+				return nil, fmt.Errorf("step %d of %s: %s", i+1, pipeline.Name, err)
+			}
+			inner = &usesStep{name: config.Uses, steps: steps}
+		} else {
+			var name string = config.Name
+			if name == "" {
+				name = config.Run
+			}
+			inner = &commandStep{
+				name:       name,
+				command:    config.Run,
+				env:        config.Env,
+				workingDir: config.WorkingDir,
+			}
+		}
+
+		step := &pipelineStep{inner: inner, condition: condition, config: config}
+		plan = append(plan, step)
+	}
+	return plan, nil
+}
+
+// pipelineStep wraps a step of a pipeline with its condition and error handling.
+type pipelineStep struct {
+	inner     Step
+	condition internal.Condition
+	config    internal.BuildConfigPipelineStep
+}
+
+func (s *pipelineStep) Name() string {
+	if s.config.Name != "" {
+		return s.config.Name
+	}
+	return s.inner.Name()
+}
+
+func (s *pipelineStep) Dependencies() []string {
+	return nil
+}
+
+func (s *pipelineStep) Run(ctx *Context) error {
+	if !s.matches(ctx) {
+		ctx.Logger.Infof("Skipping %s, `%s` doesn't hold", s.Name(), s.config.If)
+		return errStepSkipped
+	}
+
+	err := s.inner.Run(ctx)
+	if err == nil || !s.config.ContinueOnError {
+		return err
+	}
+
+	stepErr := asStepError(s, err)
+	ctx.recordFailure(stepErr)
+	ctx.Logger.Warnf("Step %s failed, continuing: %s", stepErr.Step, stepErr.Err)
+	return errStepContinued
+}
+
+func (s *pipelineStep) DryRun(ctx *Context) ([]PlanAction, error) {
+	if !s.matches(ctx) {
+		return []PlanAction{{Kind: PlanActionSkip, Summary: fmt.Sprintf("`%s` doesn't hold", s.config.If)}}, nil
+	}
+
+	actions, err := dryRunStep(ctx, s.inner)
+	if err == nil || !s.config.ContinueOnError {
+		return actions, err
+	}
+
+	stepErr := asStepError(s, err)
+	ctx.Logger.Warnf("Step %s would fail, continuing: %s", stepErr.Step, stepErr.Err)
+	return actions, nil
+}
+
+// matches returns true if the step's condition holds for the build.
+func (s *pipelineStep) matches(ctx *Context) bool {
+	values := map[string]string{
+		"os":     ctx.TargetOS.String(),
+		"arch":   ctx.Config.Godot.TargetArch().String(),
+		"branch": ctx.Branch(),
+	}
+	return s.condition.Matches(values)
+}
+
+// substeps returns the steps this step may run, so they count as planned.
+func (s *pipelineStep) substeps() []Step {
+	if uses, ok := s.inner.(*usesStep); ok {
+		return uses.steps
+	}
+	return nil
+}
+
+// usesStep runs a built-in step and the dependencies that haven't run yet.
+type usesStep struct {
+	name  string
+	steps []Step
+}
+
+func (s *usesStep) Name() string {
+	return s.name
+}
+
+func (s *usesStep) Dependencies() []string {
+	return nil
+}
+
+func (s *usesStep) Run(ctx *Context) error {
+	for _, step := range s.steps {
+		if ctx.completed[step] {
+			continue
+		}
+		if err := runStep(ctx, step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *usesStep) DryRun(ctx *Context) ([]PlanAction, error) {
+	var actions []PlanAction
+	for _, step := range s.steps {
+		if ctx.completed[step] {
+			continue
+		}
+		stepActions, err := dryRunStep(ctx, step)
+		if err != nil {
+			return nil, asStepError(step, err)
+		}
+		for _, action := range stepActions {
+			action.Step = step.Name()
+			actions = append(actions, action)
+		}
+	}
+	return actions, nil
+}
+
+// commandStep runs a shell command.
+type commandStep struct {
+	name       string
+	command    string
+	env        map[string]string
+	workingDir string
+}
+
+func (s *commandStep) Name() string {
+	return s.name
+}
+
+func (s *commandStep) Dependencies() []string {
+	return nil
+}
+
+func (s *commandStep) Run(ctx *Context) error {
+	ctx.Logger.StartGroup(s.Name())
+	defer ctx.Logger.EndGroup()
+
+	shell := s.shellArgs(ctx.TargetOS)
+	cmd := exec.Command(shell[0], shell[1:]...)
+	cmd.Dir = s.dir(ctx)
+
+	// Commands can use the Godot binary installed by earlier steps
+	cmd.Env = os.Environ()
+	if ctx.GodotBin != "" {
+		cmd.Env = append(cmd.Env, "GODOT_BIN="+ctx.GodotBin)
+	}
+	var names []string = make([]string, 0, len(s.env))
+	for name := range s.env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd.Env = append(cmd.Env, name+"="+s.env[name])
+	}
+
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			ctx.Logger.Infof("%s", scanner.Text())
+		}
+		_, _ = io.Copy(io.Discard, reader)
+	}()
+
+	ctx.Logger.Infof("Running `%s`", s.command)
+	err := cmd.Run()
+	writer.Close()
+	<-done
+
+	if err != nil {
+		return stepErrorf(ErrorKindFailure, "command `%s` failed: %s", s.command, err)
+	}
+	return nil
+}
+
+func (s *commandStep) DryRun(ctx *Context) ([]PlanAction, error) {
+	var env map[string]string = make(map[string]string, len(s.env)+1)
+	if ctx.GodotBin != "" {
+		env["GODOT_BIN"] = ctx.GodotBin
+	}
+	for name, value := range s.env {
+		env[name] = value
+	}
+
+	return []PlanAction{{
+		Kind:    PlanActionCommand,
+		Summary: s.command,
+		Command: s.shellArgs(ctx.TargetOS),
+		Dir:     s.dir(ctx),
+		Env:     env,
+	}}, nil
+}
+
+// shellArgs returns the shell invocation that runs the command on the given target OS.
+func (s *commandStep) shellArgs(targetOS internal.TargetOS) []string {
+	if targetOS == internal.TargetOSWindows {
+		return []string{"cmd", "/C", s.command}
+	}
+	return []string{"sh", "-c", s.command}
+}
+
+// dir returns the directory the command runs in.
+func (s *commandStep) dir(ctx *Context) string {
+	if s.workingDir == "" {
+		return ctx.ProjectDir
+	}
+	if filepath.IsAbs(s.workingDir) {
+		return s.workingDir
+	}
+	return filepath.Join(ctx.ProjectDir, s.workingDir)
+}
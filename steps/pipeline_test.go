@@ -0,0 +1,169 @@
+package steps
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+// fakeStep is a step that records when it runs in a shared log.
+type fakeStep struct {
+	name         string
+	dependencies []string
+	err          error
+	runs         *[]string
+}
+
+func (s *fakeStep) Name() string {
+	return s.name
+}
+
+func (s *fakeStep) Dependencies() []string {
+	return s.dependencies
+}
+
+func (s *fakeStep) Run(ctx *Context) error {
+	*s.runs = append(*s.runs, s.name)
+	return s.err
+}
+
+// newTestRegistry registers fake steps, each given as a name followed by its dependencies.
+func newTestRegistry(runs *[]string, steps ...[]string) *Registry {
+	registry := NewRegistry()
+	for _, step := range steps {
+		registry.Register(&fakeStep{name: step[0], dependencies: step[1:], runs: runs})
+	}
+	return registry
+}
+
+// newTestContext creates a context for a Linux build of an empty config.
+func newTestContext(t *testing.T) *Context {
+	return &Context{
+		Logger:     logging.NewLogger(&logging.LoggerOptions{}),
+		TargetOS:   internal.TargetOSLinux,
+		Config:     &internal.BuildConfig{},
+		ProjectDir: t.TempDir(),
+	}
+}
+
+// resultStatuses returns the name and status of each step's result.
+func resultStatuses(ctx *Context) []string {
+	var statuses []string
+	for _, result := range ctx.results {
+		statuses = append(statuses, fmt.Sprintf("%s: %s", result.name, result.status))
+	}
+	return statuses
+}
+
+func TestPlanPipelineReusesDependencies(t *testing.T) {
+	var runs []string
+	registry := newTestRegistry(&runs, []string{"resolve"}, []string{"setup", "resolve"}, []string{"templates", "resolve"}, []string{"export", "setup", "templates"})
+
+	plan, err := registry.PlanPipeline(internal.BuildConfigPipeline{Name: "release", Steps: []internal.BuildConfigPipelineStep{
+		{Uses: "setup"},
+		{Uses: "export"},
+		{Uses: "setup"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := newTestContext(t)
+	if err := Run(ctx, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"resolve", "setup", "templates", "export"}
+	if !reflect.DeepEqual(runs, expected) {
+		t.Errorf("expected steps to run once each as %v, got %v", expected, runs)
+	}
+	if !ctx.Planned("templates") {
+		t.Errorf("expected the dependencies of used steps to be planned")
+	}
+}
+
+func TestPlanPipelineContinueOnError(t *testing.T) {
+	tests := []struct {
+		continueOnError bool
+		runs            []string
+		statuses        []string
+		failed          bool
+	}{
+		{true, []string{"lint", "export"}, []string{"lint: Failed, continued", "export: Succeeded"}, false},
+		{false, []string{"lint"}, []string{"lint: Failed", "export: Not run"}, true},
+	}
+
+	for _, test := range tests {
+		var runs []string
+		registry := newTestRegistry(&runs, []string{"export"})
+		registry.Register(&fakeStep{name: "lint", err: stepErrorf(ErrorKindValidation, "lint failed"), runs: &runs})
+
+		plan, err := registry.PlanPipeline(internal.BuildConfigPipeline{Name: "release", Steps: []internal.BuildConfigPipelineStep{
+			{Uses: "lint", ContinueOnError: test.continueOnError},
+			{Uses: "export"},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := newTestContext(t)
+		err = Run(ctx, plan)
+
+		var buildErr *BuildError
+		if test.failed != errors.As(err, &buildErr) {
+			t.Errorf("continue_on_error = %v: unexpected result %v", test.continueOnError, err)
+		}
+		if !reflect.DeepEqual(runs, test.runs) {
+			t.Errorf("continue_on_error = %v: expected runs %v, got %v", test.continueOnError, test.runs, runs)
+		}
+		if statuses := resultStatuses(ctx); !reflect.DeepEqual(statuses, test.statuses) {
+			t.Errorf("continue_on_error = %v: expected results %v, got %v", test.continueOnError, test.statuses, statuses)
+		}
+		if len(ctx.failures) != 1 || ctx.failures[0].Kind != ErrorKindValidation || ctx.failures[0].Step != "lint" {
+			t.Errorf("continue_on_error = %v: expected the lint failure to be recorded, got %v", test.continueOnError, ctx.failures)
+		}
+	}
+}
+
+func TestPlanPipelineConditions(t *testing.T) {
+	var runs []string
+	registry := newTestRegistry(&runs, []string{"export"})
+
+	plan, err := registry.PlanPipeline(internal.BuildConfigPipeline{Name: "release", Steps: []internal.BuildConfigPipelineStep{
+		{Name: "Windows only", Uses: "export", If: "os == 'windows'"},
+		{Name: "Linux only", Uses: "export", If: "os == 'linux'"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := newTestContext(t)
+	if err := Run(ctx, plan); err != nil {
+		t.Fatal(err)
+	}
+	if statuses := resultStatuses(ctx); !reflect.DeepEqual(statuses, []string{"Windows only: Skipped", "Linux only: Succeeded"}) {
+		t.Errorf("unexpected results %v", statuses)
+	}
+	if !reflect.DeepEqual(runs, []string{"export"}) {
+		t.Errorf("expected export to run once, got %v", runs)
+	}
+}
+
+func TestPlanPipelineErrors(t *testing.T) {
+	var runs []string
+	registry := newTestRegistry(&runs, []string{"export"})
+
+	tests := []internal.BuildConfigPipelineStep{
+		{Uses: "package"},
+		{Uses: "export", If: "os ~= 'linux'"},
+	}
+	for _, step := range tests {
+		if _, err := registry.PlanPipeline(internal.BuildConfigPipeline{Name: "release", Steps: []internal.BuildConfigPipelineStep{step}}); err == nil {
+			t.Errorf("expected an error planning %+v", step)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package steps
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+// errStepSkipped is returned by steps that didn't run because their condition didn't hold.
+var errStepSkipped = errors.New("skipped")
+
+// errStepContinued is returned by steps that failed but let the build continue.
+var errStepContinued = errors.New("continued")
+
+// stepResult is the outcome of a step, reported in the build summary.
+type stepResult struct {
+	name     string
+	status   string
+	duration time.Duration
+	err      *StepError
+}
+
+// Run runs the planned steps in order, stopping at the first one that fails. It returns a
+// *BuildError listing the failures, and writes a summary of every step to the logger.
+func Run(ctx *Context, plan []Step) error {
+	ctx.start(plan)
+
+	var failed *StepError
+	for _, step := range plan {
+		if failed != nil {
+			ctx.results = append(ctx.results, stepResult{name: step.Name(), status: "Not run"})
+			continue
+		}
+
+		start := time.Now()
+		err := runStep(ctx, step)
+		result := stepResult{name: step.Name(), status: "Succeeded", duration: time.Since(start)}
+		switch {
+		case errors.Is(err, errStepSkipped):
+			result.status = "Skipped"
+		case errors.Is(err, errStepContinued):
+			result.status = "Failed, continued"
+			result.err = ctx.failures[len(ctx.failures)-1]
+		case err != nil:
+			failed = asStepError(step, err)
+			result.status = "Failed"
+			result.err = failed
+		}
+		ctx.results = append(ctx.results, result)
+	}
+
+	ctx.Logger.SetSummary(ctx.summary())
+	if failed == nil {
+		return nil
+	}
+
+	ctx.Logger.Errorf("Build failed at step %s (%s): %s", failed.Step, failed.Kind, failed.Err)
+	return &BuildError{Errors: ctx.failures}
+}
+
+// start resets the context for a build of the given plan.
+func (c *Context) start(plan []Step) {
+	c.planned = make(map[string]bool)
+	c.completed = make(map[Step]bool)
+	c.results = make([]stepResult, 0, len(plan))
+	c.failures = make([]*StepError, 0)
+	for _, step := range plan {
+		c.planned[step.Name()] = true
+		if parent, ok := step.(interface{ substeps() []Step }); ok {
+			for _, substep := range parent.substeps() {
+				c.planned[substep.Name()] = true
+			}
+		}
+	}
+}
+
+// runStep runs a single step, recording that it completed or why it failed.
+func runStep(ctx *Context, step Step) error {
+	previous := ctx.step
+	ctx.setStep(step.Name())
+	defer ctx.setStep(previous)
+
+	ctx.Logger.Debugf("Running step %s", step.Name())
+	err := step.Run(ctx)
+	if errors.Is(err, errStepSkipped) || errors.Is(err, errStepContinued) {
+		return err
+	}
+	if err != nil {
+		var stepErr *StepError
+		if errors.As(err, &stepErr) && stepErr.Step != "" {
+			// Already reported by the nested step that failed
+			return stepErr
+		}
+
+		stepErr = asStepError(step, err)
+		ctx.Logger.Errorf("Step %s failed: %s", stepErr.Step, stepErr.Err)
+		ctx.failures = append(ctx.failures, stepErr)
+		return stepErr
+	}
+
+	ctx.completed[step] = true
+	return nil
+}
+
+// setStep sets the step being run, telling the logger if it keeps track of steps.
+func (c *Context) setStep(name string) {
+	c.step = name
+	if stepLogger, ok := c.Logger.(logging.StepLogger); ok {
+		stepLogger.SetStep(name)
+	}
+}
+
+// recordFailure records the failure of a step that let the build continue, unless a nested step already did.
+func (c *Context) recordFailure(stepErr *StepError) {
+	for _, failure := range c.failures {
+		if failure == stepErr {
+			return
+		}
+	}
+	c.failures = append(c.failures, stepErr)
+}
+
+// asStepError returns the error as a *StepError of the given step, wrapping errors of other types.
+func asStepError(step Step, err error) *StepError {
+	var stepErr *StepError
+	if !errors.As(err, &stepErr) {
+		stepErr = &StepError{Kind: ErrorKindFailure, Err: err}
+	}
+	if stepErr.Step == "" {
+		stepErr.Step = step.Name()
+	}
+	return stepErr
+}
+
+// summary returns a markdown summary of the build's steps.
+func (c *Context) summary() string {
+	var b strings.Builder
+	b.WriteString("### Godot build\n\n")
+	b.WriteString("| Step | Result | Duration |\n")
+	b.WriteString("| --- | --- | --- |\n")
+	for _, result := range c.results {
+		status := result.status
+		if result.err != nil {
+			status = fmt.Sprintf("%s (%s in %s): %s", status, result.err.Kind, result.err.Step, result.err.Err)
+		}
+
+		var duration string
+		if result.status != "Not run" {
+			duration = result.duration.Round(time.Millisecond).String()
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", escapeTableCell(result.name), escapeTableCell(status), duration)
+	}
+
+	return b.String()
+}
+
+// escapeTableCell keeps a value from breaking out of a markdown table cell.
+func escapeTableCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.ReplaceAll(value, "\n", " ")
+}
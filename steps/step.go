@@ -0,0 +1,191 @@
+package steps
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yeslayla/godot-build-tools/internal"
+	"github.com/yeslayla/godot-build-tools/logging"
+)
+
+const (
+	StepGodotResolve    = "godot-resolve"
+	StepGodotSetup      = "godot-setup"
+	StepExportTemplates = "export-templates"
+	StepImport          = "import"
+	StepExport          = "export"
+)
+
+// Step is a single step of a build.
+type Step interface {
+	// Name returns the name used to request the step with -steps.
+	Name() string
+	// Dependencies returns the names of the steps that have to run before this one.
+	Dependencies() []string
+	// Run runs the step, returning a *StepError if it failed.
+	Run(ctx *Context) error
+}
+
+// Context is shared by the steps of a build, passing the config and the outputs of earlier steps along.
+type Context struct {
+	Logger     logging.Logger
+	TargetOS   internal.TargetOS
+	Config     *internal.BuildConfig
+	ProjectDir string
+	// Frozen fails the build instead of updating an outdated lockfile
+	Frozen bool
+
+	// GodotBin is the Godot binary, installed by godot-setup
+	GodotBin string
+	// TemplatesDir is where export-templates installed the export templates
+	TemplatesDir string
+
+	step      string
+	planned   map[string]bool
+	completed map[Step]bool
+	branch    *string
+
+	results  []stepResult
+	failures []*StepError
+}
+
+// Planned returns true if the named step is part of the build.
+func (c *Context) Planned(name string) bool {
+	return c.planned[name]
+}
+
+// Branch returns the branch being built, looking it up the first time it is needed.
+func (c *Context) Branch() string {
+	if c.branch == nil {
+		branch := internal.CurrentBranch(c.ProjectDir)
+		c.branch = &branch
+	}
+	return *c.branch
+}
+
+// Registry holds the steps a build can be made of.
+type Registry struct {
+	steps map[string]Step
+	order []string
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		steps: make(map[string]Step),
+		order: make([]string, 0),
+	}
+}
+
+// DefaultRegistry creates a registry with the built-in steps.
+func DefaultRegistry() *Registry {
+	registry := NewRegistry()
+	registry.Register(&godotResolveStep{})
+	registry.Register(&godotSetupStep{})
+	registry.Register(&exportTemplatesStep{})
+	registry.Register(&importStep{})
+	registry.Register(&exportStep{})
+	return registry
+}
+
+// Register adds a step to the registry, replacing any step of the same name.
+func (r *Registry) Register(step Step) {
+	if _, ok := r.steps[step.Name()]; !ok {
+		r.order = append(r.order, step.Name())
+	}
+	r.steps[step.Name()] = step
+}
+
+// Get returns the named step.
+func (r *Registry) Get(name string) (Step, bool) {
+	step, ok := r.steps[name]
+	return step, ok
+}
+
+// Names returns the names of every registered step, in the order they were registered.
+func (r *Registry) Names() []string {
+	return append([]string{}, r.order...)
+}
+
+// Plan returns the named steps along with their dependencies, ordered so every step runs
+// after its dependencies. Steps without dependencies between them keep the order they were
+// registered in.
+func (r *Registry) Plan(names []string) ([]Step, error) {
+	index := make(map[string]int)
+	for i, name := range r.order {
+		index[name] = i
+	}
+
+	for _, name := range names {
+		if _, ok := r.steps[name]; !ok {
+			return nil, fmt.Errorf("unknown step %q, expected one of %s", name, strings.Join(r.order, ", "))
+		}
+	}
+
+	// Collect the steps with their dependencies, catching cycles on the way
+	var needed []string = make([]string, 0, len(names))
+	state := make(map[string]int)
+	const visiting, visited = 1, 2
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		step, ok := r.steps[name]
+		if !ok {
+			return fmt.Errorf("step %q depends on unknown step %q", path[len(path)-1], name)
+		}
+
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("steps depend on each other: %s", strings.Join(append(path, name), " -> "))
+		}
+
+		state[name] = visiting
+		for _, dependency := range step.Dependencies() {
+			if err := visit(dependency, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+
+		needed = append(needed, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// Repeatedly take the earliest registered step whose dependencies have all been planned
+	sort.Slice(needed, func(i, j int) bool {
+		return index[needed[i]] < index[needed[j]]
+	})
+	var plan []Step = make([]Step, 0, len(needed))
+	planned := make(map[string]bool)
+	for len(plan) < len(needed) {
+		for _, name := range needed {
+			if planned[name] || !dependenciesPlanned(r.steps[name], planned) {
+				continue
+			}
+			plan = append(plan, r.steps[name])
+			planned[name] = true
+			break
+		}
+	}
+
+	return plan, nil
+}
+
+// dependenciesPlanned returns true if every dependency of the step has been planned.
+func dependenciesPlanned(step Step, planned map[string]bool) bool {
+	for _, dependency := range step.Dependencies() {
+		if !planned[dependency] {
+			return false
+		}
+	}
+	return true
+}
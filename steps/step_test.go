@@ -0,0 +1,102 @@
+package steps
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// planNames returns the names of the planned steps.
+func planNames(plan []Step) []string {
+	var names []string = make([]string, 0, len(plan))
+	for _, step := range plan {
+		names = append(names, step.Name())
+	}
+	return names
+}
+
+func TestRegistryPlan(t *testing.T) {
+	var runs []string
+	// Registered out of dependency order, so ordering can't rely on registration alone
+	registry := newTestRegistry(&runs,
+		[]string{"export", "import", "templates"},
+		[]string{"templates", "resolve"},
+		[]string{"resolve"},
+		[]string{"setup", "resolve"},
+		[]string{"import", "setup"},
+		[]string{"lint"},
+	)
+
+	tests := []struct {
+		names    []string
+		expected []string
+	}{
+		{[]string{"resolve"}, []string{"resolve"}},
+		{[]string{"setup"}, []string{"resolve", "setup"}},
+		// Steps without dependencies between them keep the order they were registered in
+		{[]string{"export"}, []string{"resolve", "templates", "setup", "import", "export"}},
+		{[]string{"templates", "setup"}, []string{"resolve", "templates", "setup"}},
+		{[]string{"setup", "templates"}, []string{"resolve", "templates", "setup"}},
+		{[]string{"lint", "resolve"}, []string{"resolve", "lint"}},
+		{[]string{"setup", "setup", "resolve"}, []string{"resolve", "setup"}},
+		{[]string{}, []string{}},
+	}
+
+	for _, test := range tests {
+		plan, err := registry.Plan(test.names)
+		if err != nil {
+			t.Errorf("Plan(%v): unexpected error: %s", test.names, err)
+			continue
+		}
+		if names := planNames(plan); !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("Plan(%v): expected %v, got %v", test.names, test.expected, names)
+		}
+	}
+}
+
+func TestRegistryPlanErrors(t *testing.T) {
+	var runs []string
+	registry := newTestRegistry(&runs,
+		[]string{"resolve"},
+		[]string{"setup", "resolve", "download"},
+		[]string{"a", "b"},
+		[]string{"b", "c"},
+		[]string{"c", "a"},
+		[]string{"self", "self"},
+	)
+
+	tests := []struct {
+		names    []string
+		expected string
+	}{
+		{[]string{"package"}, `unknown step "package", expected one of resolve, setup, a, b, c, self`},
+		{[]string{"resolve", "package"}, `unknown step "package"`},
+		{[]string{"setup"}, `step "setup" depends on unknown step "download"`},
+		{[]string{"a"}, "steps depend on each other: a -> b -> c -> a"},
+		{[]string{"resolve", "c"}, "steps depend on each other: c -> a -> b -> c"},
+		{[]string{"self"}, "steps depend on each other: self -> self"},
+	}
+
+	for _, test := range tests {
+		plan, err := registry.Plan(test.names)
+		if err == nil {
+			t.Errorf("Plan(%v): expected an error, got %v", test.names, planNames(plan))
+			continue
+		}
+		if !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Plan(%v): expected error to contain %q, got %q", test.names, test.expected, err)
+		}
+	}
+}
+
+func TestDefaultRegistryPlan(t *testing.T) {
+	plan, err := DefaultRegistry().Plan([]string{StepExport, StepExportTemplates})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{StepGodotResolve, StepGodotSetup, StepExportTemplates, StepImport, StepExport}
+	if names := planNames(plan); !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
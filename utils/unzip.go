@@ -10,17 +10,22 @@ import (
 	"strings"
 )
 
-// Unzip unzips a zip archive and returns the paths of the unzipped files.
+// Unzip unzips a zip archive next to itself and returns the paths of the unzipped files.
 func Unzip(archivePath string) ([]string, error) {
+	return UnzipTo(archivePath, path.Dir(archivePath))
+}
+
+// UnzipTo unzips a zip archive into the given directory and returns the paths of the unzipped files.
+func UnzipTo(archivePath string, destDir string) ([]string, error) {
 	reader, err := zip.OpenReader(archivePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open Godot package: %s", err)
 	}
 	defer reader.Close()
 
-	destDir, err := filepath.Abs(path.Dir(archivePath))
+	destDir, err = filepath.Abs(destDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get absolute path of Godot package: %s", err)
+		return nil, fmt.Errorf("failed to get absolute path of destination: %s", err)
 	}
 
 	var unzippedFiles []string = make([]string, 0)